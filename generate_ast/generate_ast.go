@@ -18,23 +18,54 @@ func main() {
 	defineAst(outputDir, "Expr", []string{
 		"Assignment : name Token, value Expr",
 		"Binary	    : left Expr, operator Token, right Expr",
-		"Call       : callee Expr, paren Token, arguments []Expr",
+		"Call       : callee Expr, paren Token, arguments []Expr, names []Token",
+		"Coalesce   : left Expr, operator Token, right Expr",
+		"CompoundAssignment : target Expr, operator Token, value Expr",
+		"Conditional : condition Expr, thenBranch Expr, elseBranch Expr",
+		"Get        : object Expr, name Token",
 		"Grouping   : expression Expr",
+		"Increment  : target Expr, operator Token, prefix bool",
+		"Index      : object Expr, bracket Token, index Expr",
+		"IndexSet   : object Expr, bracket Token, index Expr, value Expr",
+		"Interpolation : parts []Expr",
+		"Lambda     : function *FunctionStmt",
+		"List       : bracket Token, elements []Expr",
 		"Literal    : value any",
 		"Logical    : left Expr, operator Token, right Expr",
+		"Map        : brace Token, keys []Expr, values []Expr",
+		"Set        : object Expr, name Token, value Expr",
+		"Super      : keyword Token, method Token",
+		"This       : keyword Token",
 		"Unary      : operator Token, right Expr",
 		"Variable   : name Token",
 	})
 
 	defineAst(outputDir, "Stmt", []string{
+		"Break      : keyword Token",
+		"Const      : name Token, initializer Expr, doc string",
+		"Continue   : keyword Token",
 		"Expression : expression Expr",
-		"Function   : name Token, params []Token, body []Stmt",
+		"ForIn      : keyword Token, name Token, iterable Expr, body Stmt",
+		"Function   : name Token, params []Token, defaults []Expr, rest Token, body []Stmt, generator bool, doc string",
 		"If         : condition Expr, thenBranch Stmt, elseBranch Stmt",
+		"Import     : keyword Token, path Token, name Token",
+		"Match      : keyword Token, subject Expr, arms []*MatchArm",
 		"Print      : expression Expr",
 		"Block      : statements []Stmt",
+		"Class      : name Token, superclass *VariableExpr, methods []*FunctionStmt, doc string",
 		"Return     : keyword Token, value Expr",
-		"Var        : name Token, initializer Expr",
-		"While      : condition Expr, body Stmt",
+		"Throw      : keyword Token, value Expr",
+		"Try        : tryBlock []Stmt, catchName Token, catchBlock []Stmt, finallyBlock []Stmt",
+		"Var        : name Token, initializer Expr, doc string",
+		"While      : condition Expr, body Stmt, increment Expr",
+		"Yield      : keyword Token, value Expr",
+	})
+
+	defineAst(outputDir, "Pattern", []string{
+		"Alternative : alternatives []Pattern",
+		"Binding     : name Token",
+		"Literal     : token Token, value any",
+		"Wildcard    : underscore Token",
 	})
 }
 
@@ -79,8 +110,8 @@ func defineTokenType(file io.Writer, baseName string, className string, fieldLis
 	fmt.Fprintln(file, "}")
 	fmt.Fprintln(file)
 
-	fmt.Fprintf(file, "func New%s(%s) %s {\n", typeName, fieldList, typeName)
-	fmt.Fprintf(file, "  return %s{\n", typeName)
+	fmt.Fprintf(file, "func New%s(%s) *%s {\n", typeName, fieldList, typeName)
+	fmt.Fprintf(file, "  return &%s{\n", typeName)
 	for _, field := range fields {
 		field = strings.TrimSpace(field)
 		name := strings.Split(field, " ")[0]
@@ -90,7 +121,7 @@ func defineTokenType(file io.Writer, baseName string, className string, fieldLis
 	fmt.Fprintln(file, "}")
 	fmt.Fprintln(file)
 
-	fmt.Fprintf(file, "func (c %s) Accept(visitor %sVisitor) (any, LoxError) {\n", typeName, baseName)
+	fmt.Fprintf(file, "func (c *%s) Accept(visitor %sVisitor) (any, LoxError) {\n", typeName, baseName)
 	fmt.Fprintf(file, "  return visitor.Visit%s(c)\n", typeName)
 	fmt.Fprintf(file, "}\n")
 
@@ -102,7 +133,7 @@ func defineVisitor(file io.Writer, baseName string, types []string) {
 		className := strings.TrimSpace(strings.Split(aType, ":")[0])
 		typeName := defineTypeName(className, baseName)
 		base := strings.ToLower(baseName)
-		fmt.Fprintf(file, "  Visit%s(%s %s) (any, LoxError)\n", typeName, base, typeName)
+		fmt.Fprintf(file, "  Visit%s(%s *%s) (any, LoxError)\n", typeName, base, typeName)
 	}
 	fmt.Fprintln(file, "}")
 
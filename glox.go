@@ -1,8 +1,10 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
+	"path/filepath"
 
 	"github.com/chzyer/readline"
 	"github.com/mbanszel/glox/lox"
@@ -10,8 +12,16 @@ import (
 
 var interpreter = lox.NewInterpreter()
 
-func run(source string) {
+// statements end at line ends too, unless the line obviously goes on
+var optionalSemicolons = flag.Bool("optional-semicolons", false, "don't require ';' at the end of a line")
+
+// run runs the source read from the file, "" when it is typed in
+func run(source string, filename string) {
 	scanner := lox.NewScanner(source)
+	if filename != "" {
+		scanner.SetFile(filename)
+	}
+	scanner.SetOptionalSemicolons(*optionalSemicolons)
 	tokens := scanner.ScanTokens()
 
 	// for _, a_token := range tokens {
@@ -23,6 +33,12 @@ func run(source string) {
 	if lox.HadError {
 		return
 	}
+
+	resolver := lox.NewResolver(interpreter)
+	resolver.Resolve(statements)
+	if lox.HadError {
+		return
+	}
 	interpreter.Interpret(statements)
 }
 
@@ -33,7 +49,8 @@ func runFile(filename string) {
 		panic("exiting")
 	}
 
-	run(string(bytes))
+	interpreter.SetScript(filename)
+	run(string(bytes), filename)
 	if lox.HadError {
 		os.Exit(65)
 	}
@@ -68,18 +85,27 @@ func runPrompt() {
 			break
 		}
 
-		run(line)
+		run(line, "")
 
 	}
 }
 
 func main() {
-	if len(os.Args) > 2 {
-		fmt.Println("Usage: glox [script]")
+	flag.Usage = func() {
+		fmt.Println("Usage: glox [-optional-semicolons] [script]")
+	}
+	flag.Parse()
+
+	// modules not found next to the importing file are searched for here
+	interpreter.SetSearchPath(filepath.SplitList(os.Getenv("GLOX_PATH")))
+	interpreter.SetOptionalSemicolons(*optionalSemicolons)
+
+	if flag.NArg() > 1 {
+		flag.Usage()
 		// TODO: diceide on the exit code
 		os.Exit(1)
-	} else if len(os.Args) == 2 {
-		runFile(os.Args[1])
+	} else if flag.NArg() == 1 {
+		runFile(flag.Arg(0))
 	} else {
 		runPrompt()
 	}
@@ -2,6 +2,7 @@ package lox
 
 import (
 	"fmt"
+	"strings"
 )
 
 type AstPrinter struct {
@@ -9,19 +10,19 @@ type AstPrinter struct {
 }
 
 // VisitAssignmentExpr implements ExprVisitor.
-func (p AstPrinter) VisitAssignmentExpr(expr AssignmentExpr) (any, LoxError) {
+func (p AstPrinter) VisitAssignmentExpr(expr *AssignmentExpr) (any, LoxError) {
 	panic("unimplemented")
 }
-func (p AstPrinter) VisitBlockStmt(expr BlockStmt) (any, LoxError) {
+func (p AstPrinter) VisitBlockStmt(expr *BlockStmt) (any, LoxError) {
 	panic("unimplemented")
 }
-func (p AstPrinter) VisitIfStmt(expr BlockStmt) (any, LoxError) {
+func (p AstPrinter) VisitIfStmt(expr *BlockStmt) (any, LoxError) {
 	panic("unimplemented")
 }
-func (p AstPrinter) VisitLogicalExpr(expr LogicalExpr) (any, LoxError) {
-	panic("unimplemented")
+func (p AstPrinter) VisitLogicalExpr(expr *LogicalExpr) (any, LoxError) {
+	return p.parenthesize(expr.operator.Lexeme, expr.left, expr.right)
 }
-func (p AstPrinter) VisitCallExpr(expr CallExpr) (any, LoxError) {
+func (p AstPrinter) VisitCallExpr(expr *CallExpr) (any, LoxError) {
 	panic("unimplemented")
 }
 
@@ -44,30 +45,100 @@ func (p AstPrinter) Print(e Expr) string {
 
 
 
-func (p AstPrinter) VisitBinaryExpr(expr BinaryExpr) (any, LoxError) {
+func (p AstPrinter) VisitCoalesceExpr(expr *CoalesceExpr) (any, LoxError) {
+	return p.parenthesize(expr.operator.Lexeme, expr.left, expr.right)
+}
+
+func (p AstPrinter) VisitCompoundAssignmentExpr(expr *CompoundAssignmentExpr) (any, LoxError) {
+	return p.parenthesize(expr.operator.Lexeme, expr.target, expr.value)
+}
+
+func (p AstPrinter) VisitConditionalExpr(expr *ConditionalExpr) (any, LoxError) {
+	return p.parenthesize("?:", expr.condition, expr.thenBranch, expr.elseBranch)
+}
+
+func (p AstPrinter) VisitBinaryExpr(expr *BinaryExpr) (any, LoxError) {
 	return p.parenthesize(expr.operator.Lexeme, expr.left, expr.right)
 }
 
-func (p AstPrinter) VisitGroupingExpr(expr GroupingExpr) (any, LoxError) {
+func (p AstPrinter) VisitGroupingExpr(expr *GroupingExpr) (any, LoxError) {
 	return p.parenthesize("group", expr.expression)
 }
 
-func (p AstPrinter) VisitLiteralExpr(expr LiteralExpr) (any, LoxError) {
+func (p AstPrinter) VisitIncrementExpr(expr *IncrementExpr) (any, LoxError) {
+	if expr.prefix {
+		return p.parenthesize("pre"+expr.operator.Lexeme, expr.target)
+	}
+	return p.parenthesize("post"+expr.operator.Lexeme, expr.target)
+}
+
+func (p AstPrinter) VisitIndexExpr(expr *IndexExpr) (any, LoxError) {
+	return p.parenthesize("[]", expr.object, expr.index)
+}
+
+func (p AstPrinter) VisitIndexSetExpr(expr *IndexSetExpr) (any, LoxError) {
+	return p.parenthesize("[]=", expr.object, expr.index, expr.value)
+}
+
+func (p AstPrinter) VisitInterpolationExpr(expr *InterpolationExpr) (any, LoxError) {
+	return p.parenthesize("str", expr.parts...)
+}
+
+func (p AstPrinter) VisitLambdaExpr(expr *LambdaExpr) (any, LoxError) {
+	var params []string
+	for _, param := range expr.function.params {
+		params = append(params, param.Lexeme)
+	}
+	if expr.function.rest.Lexeme != "" {
+		params = append(params, "..."+expr.function.rest.Lexeme)
+	}
+	return fmt.Sprintf("(fun (%s) ...)", strings.Join(params, " ")), nil
+}
+
+func (p AstPrinter) VisitListExpr(expr *ListExpr) (any, LoxError) {
+	return p.parenthesize("list", expr.elements...)
+}
+
+func (p AstPrinter) VisitLiteralExpr(expr *LiteralExpr) (any, LoxError) {
 	if expr.value == nil {
 		return "nil", nil
 	}
 	return fmt.Sprintf("%v", expr.value), nil
 }
 
-func (p AstPrinter) VisitUnaryExpr(expr UnaryExpr) (any, LoxError) {
+func (p AstPrinter) VisitUnaryExpr(expr *UnaryExpr) (any, LoxError) {
 	return p.parenthesize(expr.operator.Lexeme, expr.right)
 }
 
-func (p AstPrinter) VisitVariableExpr(expr VariableExpr) (any, LoxError) {
+func (p AstPrinter) VisitGetExpr(expr *GetExpr) (any, LoxError) {
+	return p.parenthesize("."+expr.name.Lexeme, expr.object)
+}
+
+func (p AstPrinter) VisitMapExpr(expr *MapExpr) (any, LoxError) {
+	entries := make([]Expr, 0, 2*len(expr.keys))
+	for j := range expr.keys {
+		entries = append(entries, expr.keys[j], expr.values[j])
+	}
+	return p.parenthesize("map", entries...)
+}
+
+func (p AstPrinter) VisitSetExpr(expr *SetExpr) (any, LoxError) {
+	return p.parenthesize("."+expr.name.Lexeme+"=", expr.object, expr.value)
+}
+
+func (p AstPrinter) VisitSuperExpr(expr *SuperExpr) (any, LoxError) {
+	return "super." + expr.method.Lexeme, nil
+}
+
+func (p AstPrinter) VisitThisExpr(expr *ThisExpr) (any, LoxError) {
+	return expr.keyword.Lexeme, nil
+}
+
+func (p AstPrinter) VisitVariableExpr(expr *VariableExpr) (any, LoxError) {
 	return expr.name.Lexeme, nil
 }
 
-func (p AstPrinter) VisitVarStmt(stmt VarStmt) (any, LoxError) {
+func (p AstPrinter) VisitVarStmt(stmt *VarStmt) (any, LoxError) {
 	return p.parenthesize(fmt.Sprintf("(var %s)", stmt.initializer))
 }
 
@@ -7,22 +7,22 @@ import (
 var testcases = []Expr {
 	NewBinaryExpr(
 		NewUnaryExpr(
-			Token{MINUS, "-", nil, 1},
+			Token{MINUS, "-", nil, 1, 0, 0, ""},
 			NewLiteralExpr(123),
 		),
-		Token{STAR, "*", nil, 1},
+		Token{STAR, "*", nil, 1, 0, 0, ""},
 		NewGroupingExpr(NewLiteralExpr(45.67)),
 	),
 	NewBinaryExpr(
 		NewBinaryExpr(
 			NewLiteralExpr(1),
-			Token{PLUS, "+", nil, 1},
+			Token{PLUS, "+", nil, 1, 0, 0, ""},
 			NewLiteralExpr(2),
 		),
-		Token{STAR, "*", nil, 1},
+		Token{STAR, "*", nil, 1, 0, 0, ""},
 		NewBinaryExpr(
 			NewLiteralExpr(3),
-			Token{MINUS, "-", nil, 1},
+			Token{MINUS, "-", nil, 1, 0, 0, ""},
 			NewLiteralExpr(4),
 		),
 	),
@@ -49,13 +49,13 @@ func TestAstRPNPrinter(t *testing.T) {
 	expr := NewBinaryExpr(
 		NewBinaryExpr(
 			NewLiteralExpr(1),
-			Token{PLUS, "+", nil, 1},
+			Token{PLUS, "+", nil, 1, 0, 0, ""},
 			NewLiteralExpr(2),
 		),
-		Token{STAR, "*", nil, 1},
+		Token{STAR, "*", nil, 1, 0, 0, ""},
 		NewBinaryExpr(
 			NewLiteralExpr(3),
-			Token{MINUS, "-", nil, 1},
+			Token{MINUS, "-", nil, 1, 0, 0, ""},
 			NewLiteralExpr(4),
 		),
 	)
@@ -67,3 +67,44 @@ func TestAstRPNPrinter(t *testing.T) {
 		t.Errorf("failed: %s != %s", result, expected)
 	}
 }
+
+func TestArithmeticOperatorPrecedence(t *testing.T) {
+	testcases := map[string]string{
+		"-2 ** 2;":         "(- (** 2 2))",
+		"2 ** 3 ** 2;":     "(** 2 (** 3 2))",
+		"2 ** -1;":         "(** 2 (- 1))",
+		"1 + 7 % 4 * 2;":   "(+ 1 (* (% 7 4) 2))",
+		"9 ~/ 2 - 1;":      "(- (~/ 9 2) 1)",
+		"2 * 3 ** 2 ~/ 4;": "(~/ (* 2 (** 3 2)) 4)",
+	}
+
+	printer := NewAstPrinter()
+	for source, expected := range testcases {
+		scanner := NewScanner(source)
+		statements := NewParser(scanner.ScanTokens()).Parse()
+		result := printer.Print(statements[0].(*ExpressionStmt).expression)
+		if result != expected {
+			t.Errorf("failed: %s printed as %s, expected %s", source, result, expected)
+		}
+	}
+}
+
+func TestConditionalPrecedence(t *testing.T) {
+	testcases := map[string]string{
+		"a ? b : c ? d : e;": "(?: a b (?: c d e))",
+		"a or b ? c : d;":    "(?: (or a b) c d)",
+		"a ?? b ?? c;":       "(?? (?? a b) c)",
+		"a ?? b or c;":       "(?? a (or b c))",
+		"a ?? b ? c : d;":    "(?: (?? a b) c d)",
+	}
+
+	printer := NewAstPrinter()
+	for source, expected := range testcases {
+		scanner := NewScanner(source)
+		statements := NewParser(scanner.ScanTokens()).Parse()
+		result := printer.Print(statements[0].(*ExpressionStmt).expression)
+		if result != expected {
+			t.Errorf("failed: %s printed as %s, expected %s", source, result, expected)
+		}
+	}
+}
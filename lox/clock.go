@@ -6,8 +6,8 @@ import (
 
 type ClockNativeFunction struct{}
 
-func (c ClockNativeFunction) Arity() int {
-	return 0
+func (c ClockNativeFunction) Arity() (int, int) {
+	return 0, 0
 }
 
 func (c ClockNativeFunction) Call(i *Interpreter, arguments []any) (any, LoxError) {
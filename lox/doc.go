@@ -0,0 +1,20 @@
+package lox
+
+// The doc comment of a declaration is the text of the '///' comments right
+// before it, one line per comment, or "" when there are none.
+
+func (stmt *FunctionStmt) GetDoc() string {
+	return stmt.doc
+}
+
+func (stmt *ClassStmt) GetDoc() string {
+	return stmt.doc
+}
+
+func (stmt *VarStmt) GetDoc() string {
+	return stmt.doc
+}
+
+func (stmt *ConstStmt) GetDoc() string {
+	return stmt.doc
+}
@@ -4,24 +4,67 @@ import (
 	"fmt"
 )
 
+// uninitialized is the value of a variable declared without an initializer,
+// reading it is an error (unlike reading a variable explicitly set to nil)
+type uninitializedValue struct{}
+
+var uninitialized = uninitializedValue{}
+
 type Environment struct {
 	Enclosing *Environment
 	Values map[string]any
+	// names of the values that can't be assigned to
+	constants map[string]bool
 }
 
 func NewEnvironment(enclosing *Environment) *Environment {
 	return &Environment{
 		Enclosing: enclosing,
 		Values: make(map[string]any),
+		constants: make(map[string]bool),
 	}
 }
 
+// define is for the names the interpreter itself defines (natives, 'this',
+// parameters), declarations go through declare
 func (e *Environment) define(name string, value any) {
 	e.Values[name] = value
 }
 
+// declare defines the name of a var, fun, class or import declaration. A
+// constant can't be redeclared, not even where the Resolver does not see the
+// constant (an earlier line in the REPL, say).
+func (e *Environment) declare(name Token, value any) RuntimeError {
+	if e.constants[name.Lexeme] {
+		return &RuntimeErrorObj{
+			name,
+			fmt.Sprintf("Can't redeclare constant '%s'.", name.Lexeme),
+		}
+	}
+	e.define(name.Lexeme, value)
+	return nil
+}
+
+func (e *Environment) declareConstant(name Token, value any) RuntimeError {
+	if err := e.declare(name, value); err != nil {
+		return err
+	}
+	e.constants[name.Lexeme] = true
+	return nil
+}
+
+func constantError(name Token) RuntimeError {
+	return &RuntimeErrorObj{
+		name,
+		fmt.Sprintf("Can't assign to constant '%s'.", name.Lexeme),
+	}
+}
+
 func (e *Environment) assign(name Token, value any) (any, RuntimeError) {
 	if _, ok := e.Values[name.Lexeme]; ok {
+		if e.constants[name.Lexeme] {
+			return nil, constantError(name)
+		}
 		e.Values[name.Lexeme] = value
 		return value, nil
 	}
@@ -36,7 +79,7 @@ func (e *Environment) assign(name Token, value any) (any, RuntimeError) {
 
 func (e *Environment) get(name Token) (any, RuntimeError) {
 	if value, ok := e.Values[name.Lexeme]; ok {
-		if value == nil {
+		if value == uninitialized {
 			return "", &RuntimeErrorObj{
 				name,
 				fmt.Sprintf("Uninitialized variable '%s'", name.Lexeme),
@@ -48,4 +91,31 @@ func (e *Environment) get(name Token) (any, RuntimeError) {
 		return e.Enclosing.get(name)
 	}
 	return "", &RuntimeErrorObj{name, "Undefined variable '" + name.Lexeme + "'"}
-}
\ No newline at end of file
+}
+func (e *Environment) ancestor(distance int) *Environment {
+	environment := e
+	for range distance {
+		environment = environment.Enclosing
+	}
+	return environment
+}
+
+func (e *Environment) getAt(distance int, name Token) (any, RuntimeError) {
+	value := e.ancestor(distance).Values[name.Lexeme]
+	if value == uninitialized {
+		return "", &RuntimeErrorObj{
+			name,
+			fmt.Sprintf("Uninitialized variable '%s'", name.Lexeme),
+		}
+	}
+	return value, nil
+}
+
+func (e *Environment) assignAt(distance int, name Token, value any) (any, RuntimeError) {
+	environment := e.ancestor(distance)
+	if environment.constants[name.Lexeme] {
+		return nil, constantError(name)
+	}
+	environment.Values[name.Lexeme] = value
+	return value, nil
+}
@@ -20,6 +20,8 @@ func Report(line int, where string, message string) {
 func Error(token Token, message string) {
 	if token.TokenType == EOF {
 		Report(token.Line, " at end", message)
+	} else if token.TokenType == EOL {
+		Report(token.Line, " at end of line", message)
 	} else {
 		Report(
 			token.Line,
@@ -32,7 +34,11 @@ func Error(token Token, message string) {
 func parserError(err ParserError) {
 	token := err.GetToken()
 	message := err.GetMessage()
-	fmt.Fprintf(os.Stderr, "[line %v] %s at %s\n", token.Line, message, token.Lexeme)
+	where := token.Lexeme
+	if token.TokenType == EOL {
+		where = "end of line"
+	}
+	fmt.Fprintf(os.Stderr, "[line %v] %s at %s\n", token.Line, message, where)
 	HadError = true
 }
 
@@ -41,7 +47,13 @@ type LoxError interface{
 	GetMessage() string
 }
 
-func runtimeError(err RuntimeError) {
-	fmt.Fprintf(os.Stderr, "[line %v] %s\n", err.GetToken().Line, err.GetMessage())
+// runtimeError reports an error, naming the file it happened in when that is
+// not the script being run
+func runtimeError(err RuntimeError, script string) {
+	where := ""
+	if file := err.GetToken().File; file != "" && file != script {
+		where = fmt.Sprintf(" (in %s)", displayPath(file))
+	}
+	fmt.Fprintf(os.Stderr, "[line %v] %s%s\n", err.GetToken().Line, err.GetMessage(), where)
 	HadRuntimeError = true
 }
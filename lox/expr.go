@@ -2,14 +2,28 @@
 package lox
 
 type ExprVisitor interface {
-  VisitAssignmentExpr(expr AssignmentExpr) (any, LoxError)
-  VisitBinaryExpr(expr BinaryExpr) (any, LoxError)
-  VisitCallExpr(expr CallExpr) (any, LoxError)
-  VisitGroupingExpr(expr GroupingExpr) (any, LoxError)
-  VisitLiteralExpr(expr LiteralExpr) (any, LoxError)
-  VisitLogicalExpr(expr LogicalExpr) (any, LoxError)
-  VisitUnaryExpr(expr UnaryExpr) (any, LoxError)
-  VisitVariableExpr(expr VariableExpr) (any, LoxError)
+  VisitAssignmentExpr(expr *AssignmentExpr) (any, LoxError)
+  VisitBinaryExpr(expr *BinaryExpr) (any, LoxError)
+  VisitCallExpr(expr *CallExpr) (any, LoxError)
+  VisitCoalesceExpr(expr *CoalesceExpr) (any, LoxError)
+  VisitCompoundAssignmentExpr(expr *CompoundAssignmentExpr) (any, LoxError)
+  VisitConditionalExpr(expr *ConditionalExpr) (any, LoxError)
+  VisitGetExpr(expr *GetExpr) (any, LoxError)
+  VisitGroupingExpr(expr *GroupingExpr) (any, LoxError)
+  VisitIncrementExpr(expr *IncrementExpr) (any, LoxError)
+  VisitIndexExpr(expr *IndexExpr) (any, LoxError)
+  VisitIndexSetExpr(expr *IndexSetExpr) (any, LoxError)
+  VisitInterpolationExpr(expr *InterpolationExpr) (any, LoxError)
+  VisitLambdaExpr(expr *LambdaExpr) (any, LoxError)
+  VisitListExpr(expr *ListExpr) (any, LoxError)
+  VisitLiteralExpr(expr *LiteralExpr) (any, LoxError)
+  VisitLogicalExpr(expr *LogicalExpr) (any, LoxError)
+  VisitMapExpr(expr *MapExpr) (any, LoxError)
+  VisitSetExpr(expr *SetExpr) (any, LoxError)
+  VisitSuperExpr(expr *SuperExpr) (any, LoxError)
+  VisitThisExpr(expr *ThisExpr) (any, LoxError)
+  VisitUnaryExpr(expr *UnaryExpr) (any, LoxError)
+  VisitVariableExpr(expr *VariableExpr) (any, LoxError)
 }
 
 type Expr interface {
@@ -22,14 +36,14 @@ type AssignmentExpr struct {
   value Expr
 }
 
-func NewAssignmentExpr(name Token, value Expr) AssignmentExpr {
-  return AssignmentExpr{
+func NewAssignmentExpr(name Token, value Expr) *AssignmentExpr {
+  return &AssignmentExpr{
     name:name,
     value:value,
   }
 }
 
-func (c AssignmentExpr) Accept(visitor ExprVisitor) (any, LoxError) {
+func (c *AssignmentExpr) Accept(visitor ExprVisitor) (any, LoxError) {
   return visitor.VisitAssignmentExpr(c)
 }
 //  -------------------------------------------------------------
@@ -39,15 +53,15 @@ type BinaryExpr struct {
   right Expr
 }
 
-func NewBinaryExpr(left Expr, operator Token, right Expr) BinaryExpr {
-  return BinaryExpr{
+func NewBinaryExpr(left Expr, operator Token, right Expr) *BinaryExpr {
+  return &BinaryExpr{
     left:left,
     operator:operator,
     right:right,
   }
 }
 
-func (c BinaryExpr) Accept(visitor ExprVisitor) (any, LoxError) {
+func (c *BinaryExpr) Accept(visitor ExprVisitor) (any, LoxError) {
   return visitor.VisitBinaryExpr(c)
 }
 //  -------------------------------------------------------------
@@ -55,45 +69,217 @@ type CallExpr struct {
   callee Expr
   paren Token
   arguments []Expr
+  names []Token
 }
 
-func NewCallExpr(callee Expr, paren Token, arguments []Expr) CallExpr {
-  return CallExpr{
+func NewCallExpr(callee Expr, paren Token, arguments []Expr, names []Token) *CallExpr {
+  return &CallExpr{
     callee:callee,
     paren:paren,
     arguments:arguments,
+    names:names,
   }
 }
 
-func (c CallExpr) Accept(visitor ExprVisitor) (any, LoxError) {
+func (c *CallExpr) Accept(visitor ExprVisitor) (any, LoxError) {
   return visitor.VisitCallExpr(c)
 }
 //  -------------------------------------------------------------
+type CoalesceExpr struct {
+  left Expr
+  operator Token
+  right Expr
+}
+
+func NewCoalesceExpr(left Expr, operator Token, right Expr) *CoalesceExpr {
+  return &CoalesceExpr{
+    left:left,
+    operator:operator,
+    right:right,
+  }
+}
+
+func (c *CoalesceExpr) Accept(visitor ExprVisitor) (any, LoxError) {
+  return visitor.VisitCoalesceExpr(c)
+}
+//  -------------------------------------------------------------
+type CompoundAssignmentExpr struct {
+  target Expr
+  operator Token
+  value Expr
+}
+
+func NewCompoundAssignmentExpr(target Expr, operator Token, value Expr) *CompoundAssignmentExpr {
+  return &CompoundAssignmentExpr{
+    target:target,
+    operator:operator,
+    value:value,
+  }
+}
+
+func (c *CompoundAssignmentExpr) Accept(visitor ExprVisitor) (any, LoxError) {
+  return visitor.VisitCompoundAssignmentExpr(c)
+}
+//  -------------------------------------------------------------
+type ConditionalExpr struct {
+  condition Expr
+  thenBranch Expr
+  elseBranch Expr
+}
+
+func NewConditionalExpr(condition Expr, thenBranch Expr, elseBranch Expr) *ConditionalExpr {
+  return &ConditionalExpr{
+    condition:condition,
+    thenBranch:thenBranch,
+    elseBranch:elseBranch,
+  }
+}
+
+func (c *ConditionalExpr) Accept(visitor ExprVisitor) (any, LoxError) {
+  return visitor.VisitConditionalExpr(c)
+}
+//  -------------------------------------------------------------
+type GetExpr struct {
+  object Expr
+  name Token
+}
+
+func NewGetExpr(object Expr, name Token) *GetExpr {
+  return &GetExpr{
+    object:object,
+    name:name,
+  }
+}
+
+func (c *GetExpr) Accept(visitor ExprVisitor) (any, LoxError) {
+  return visitor.VisitGetExpr(c)
+}
+//  -------------------------------------------------------------
 type GroupingExpr struct {
   expression Expr
 }
 
-func NewGroupingExpr(expression Expr) GroupingExpr {
-  return GroupingExpr{
+func NewGroupingExpr(expression Expr) *GroupingExpr {
+  return &GroupingExpr{
     expression:expression,
   }
 }
 
-func (c GroupingExpr) Accept(visitor ExprVisitor) (any, LoxError) {
+func (c *GroupingExpr) Accept(visitor ExprVisitor) (any, LoxError) {
   return visitor.VisitGroupingExpr(c)
 }
 //  -------------------------------------------------------------
+type IncrementExpr struct {
+  target Expr
+  operator Token
+  prefix bool
+}
+
+func NewIncrementExpr(target Expr, operator Token, prefix bool) *IncrementExpr {
+  return &IncrementExpr{
+    target:target,
+    operator:operator,
+    prefix:prefix,
+  }
+}
+
+func (c *IncrementExpr) Accept(visitor ExprVisitor) (any, LoxError) {
+  return visitor.VisitIncrementExpr(c)
+}
+//  -------------------------------------------------------------
+type IndexExpr struct {
+  object Expr
+  bracket Token
+  index Expr
+}
+
+func NewIndexExpr(object Expr, bracket Token, index Expr) *IndexExpr {
+  return &IndexExpr{
+    object:object,
+    bracket:bracket,
+    index:index,
+  }
+}
+
+func (c *IndexExpr) Accept(visitor ExprVisitor) (any, LoxError) {
+  return visitor.VisitIndexExpr(c)
+}
+//  -------------------------------------------------------------
+type IndexSetExpr struct {
+  object Expr
+  bracket Token
+  index Expr
+  value Expr
+}
+
+func NewIndexSetExpr(object Expr, bracket Token, index Expr, value Expr) *IndexSetExpr {
+  return &IndexSetExpr{
+    object:object,
+    bracket:bracket,
+    index:index,
+    value:value,
+  }
+}
+
+func (c *IndexSetExpr) Accept(visitor ExprVisitor) (any, LoxError) {
+  return visitor.VisitIndexSetExpr(c)
+}
+//  -------------------------------------------------------------
+type InterpolationExpr struct {
+  parts []Expr
+}
+
+func NewInterpolationExpr(parts []Expr) *InterpolationExpr {
+  return &InterpolationExpr{
+    parts:parts,
+  }
+}
+
+func (c *InterpolationExpr) Accept(visitor ExprVisitor) (any, LoxError) {
+  return visitor.VisitInterpolationExpr(c)
+}
+//  -------------------------------------------------------------
+type LambdaExpr struct {
+  function *FunctionStmt
+}
+
+func NewLambdaExpr(function *FunctionStmt) *LambdaExpr {
+  return &LambdaExpr{
+    function:function,
+  }
+}
+
+func (c *LambdaExpr) Accept(visitor ExprVisitor) (any, LoxError) {
+  return visitor.VisitLambdaExpr(c)
+}
+//  -------------------------------------------------------------
+type ListExpr struct {
+  bracket Token
+  elements []Expr
+}
+
+func NewListExpr(bracket Token, elements []Expr) *ListExpr {
+  return &ListExpr{
+    bracket:bracket,
+    elements:elements,
+  }
+}
+
+func (c *ListExpr) Accept(visitor ExprVisitor) (any, LoxError) {
+  return visitor.VisitListExpr(c)
+}
+//  -------------------------------------------------------------
 type LiteralExpr struct {
   value any
 }
 
-func NewLiteralExpr(value any) LiteralExpr {
-  return LiteralExpr{
+func NewLiteralExpr(value any) *LiteralExpr {
+  return &LiteralExpr{
     value:value,
   }
 }
 
-func (c LiteralExpr) Accept(visitor ExprVisitor) (any, LoxError) {
+func (c *LiteralExpr) Accept(visitor ExprVisitor) (any, LoxError) {
   return visitor.VisitLiteralExpr(c)
 }
 //  -------------------------------------------------------------
@@ -103,31 +289,97 @@ type LogicalExpr struct {
   right Expr
 }
 
-func NewLogicalExpr(left Expr, operator Token, right Expr) LogicalExpr {
-  return LogicalExpr{
+func NewLogicalExpr(left Expr, operator Token, right Expr) *LogicalExpr {
+  return &LogicalExpr{
     left:left,
     operator:operator,
     right:right,
   }
 }
 
-func (c LogicalExpr) Accept(visitor ExprVisitor) (any, LoxError) {
+func (c *LogicalExpr) Accept(visitor ExprVisitor) (any, LoxError) {
   return visitor.VisitLogicalExpr(c)
 }
 //  -------------------------------------------------------------
+type MapExpr struct {
+  brace Token
+  keys []Expr
+  values []Expr
+}
+
+func NewMapExpr(brace Token, keys []Expr, values []Expr) *MapExpr {
+  return &MapExpr{
+    brace:brace,
+    keys:keys,
+    values:values,
+  }
+}
+
+func (c *MapExpr) Accept(visitor ExprVisitor) (any, LoxError) {
+  return visitor.VisitMapExpr(c)
+}
+//  -------------------------------------------------------------
+type SetExpr struct {
+  object Expr
+  name Token
+  value Expr
+}
+
+func NewSetExpr(object Expr, name Token, value Expr) *SetExpr {
+  return &SetExpr{
+    object:object,
+    name:name,
+    value:value,
+  }
+}
+
+func (c *SetExpr) Accept(visitor ExprVisitor) (any, LoxError) {
+  return visitor.VisitSetExpr(c)
+}
+//  -------------------------------------------------------------
+type SuperExpr struct {
+  keyword Token
+  method Token
+}
+
+func NewSuperExpr(keyword Token, method Token) *SuperExpr {
+  return &SuperExpr{
+    keyword:keyword,
+    method:method,
+  }
+}
+
+func (c *SuperExpr) Accept(visitor ExprVisitor) (any, LoxError) {
+  return visitor.VisitSuperExpr(c)
+}
+//  -------------------------------------------------------------
+type ThisExpr struct {
+  keyword Token
+}
+
+func NewThisExpr(keyword Token) *ThisExpr {
+  return &ThisExpr{
+    keyword:keyword,
+  }
+}
+
+func (c *ThisExpr) Accept(visitor ExprVisitor) (any, LoxError) {
+  return visitor.VisitThisExpr(c)
+}
+//  -------------------------------------------------------------
 type UnaryExpr struct {
   operator Token
   right Expr
 }
 
-func NewUnaryExpr(operator Token, right Expr) UnaryExpr {
-  return UnaryExpr{
+func NewUnaryExpr(operator Token, right Expr) *UnaryExpr {
+  return &UnaryExpr{
     operator:operator,
     right:right,
   }
 }
 
-func (c UnaryExpr) Accept(visitor ExprVisitor) (any, LoxError) {
+func (c *UnaryExpr) Accept(visitor ExprVisitor) (any, LoxError) {
   return visitor.VisitUnaryExpr(c)
 }
 //  -------------------------------------------------------------
@@ -135,12 +387,12 @@ type VariableExpr struct {
   name Token
 }
 
-func NewVariableExpr(name Token) VariableExpr {
-  return VariableExpr{
+func NewVariableExpr(name Token) *VariableExpr {
+  return &VariableExpr{
     name:name,
   }
 }
 
-func (c VariableExpr) Accept(visitor ExprVisitor) (any, LoxError) {
+func (c *VariableExpr) Accept(visitor ExprVisitor) (any, LoxError) {
   return visitor.VisitVariableExpr(c)
 }
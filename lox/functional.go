@@ -0,0 +1,75 @@
+package lox
+
+import (
+	"sort"
+)
+
+// natives taking a function as an argument
+
+var functionalNatives = []*NativeFunction{
+	NewNativeFunction("map", 2, nativeMap),
+	NewNativeFunction("filter", 2, nativeFilter),
+	NewNativeFunction("sort", 2, nativeSort),
+}
+
+// map(list, fn) returns a new list with fn applied to every element
+func nativeMap(i *Interpreter, arguments []any) (any, LoxError) {
+	list, ok := arguments[0].(*LoxList)
+	if !ok {
+		return nil, nativeError("Can only map over a list")
+	}
+	elements := make([]any, 0, len(list.elements))
+	for _, element := range list.elements {
+		value, err := callFunction(i, arguments[1], element)
+		if err != nil {
+			return nil, err
+		}
+		elements = append(elements, value)
+	}
+	return NewLoxList(elements), nil
+}
+
+// filter(list, fn) returns a new list with the elements for which fn is truthy
+func nativeFilter(i *Interpreter, arguments []any) (any, LoxError) {
+	list, ok := arguments[0].(*LoxList)
+	if !ok {
+		return nil, nativeError("Can only filter a list")
+	}
+	elements := []any{}
+	for _, element := range list.elements {
+		keep, err := callFunction(i, arguments[1], element)
+		if err != nil {
+			return nil, err
+		}
+		if truthy, _ := i.isTruthy(keep); truthy {
+			elements = append(elements, element)
+		}
+	}
+	return NewLoxList(elements), nil
+}
+
+// sort(list, less) returns a new, stably sorted list; less(a, b) tells
+// whether a goes before b
+func nativeSort(i *Interpreter, arguments []any) (any, LoxError) {
+	list, ok := arguments[0].(*LoxList)
+	if !ok {
+		return nil, nativeError("Can only sort a list")
+	}
+	elements := append([]any{}, list.elements...)
+
+	// the first error stops the comparisons that matter, sort still finishes
+	var err LoxError
+	sort.SliceStable(elements, func(a, b int) bool {
+		if err != nil {
+			return false
+		}
+		var less any
+		less, err = callFunction(i, arguments[1], elements[a], elements[b])
+		truthy, _ := i.isTruthy(less)
+		return err == nil && truthy
+	})
+	if err != nil {
+		return nil, err
+	}
+	return NewLoxList(elements), nil
+}
@@ -2,30 +2,194 @@ package lox
 
 import (
 	"fmt"
+	"math"
 	"strings"
 )
 
 type Interpreter struct {
+	// natives and the prelude, shared by the globals of all modules
+	builtins    *Environment
 	globals     *Environment
 	environment *Environment
+	// scope distance of every local variable access, filled in by the Resolver
+	locals map[Expr]int
+	// return statements whose call is run in place of the returning function
+	// rather than nested in it, filled in by the Resolver
+	tailCalls map[*ReturnStmt]bool
+	// class of the values runtime errors are turned into when they are caught
+	errorClass *LoxClass
+
+	// file being run, runtime errors in other files name their file
+	script     string
+	searchPath []string
+	// whether modules are scanned with optional semicolons, like the script
+	optionalSemicolons bool
+	// loaded modules by absolute path
+	modules map[string]*LoxModule
+	// files being loaded, the innermost last, used to detect import cycles
+	loading []string
+	// generator whose body is running, nil outside of generators
+	generator *generatorRoutine
 }
 
 // VisitAssignmentExpr implements ExprVisitor.
-func (i *Interpreter) VisitAssignmentExpr(expr AssignmentExpr) (any, LoxError) {
+func (i *Interpreter) VisitAssignmentExpr(expr *AssignmentExpr) (any, LoxError) {
 	value, err := i.evaluate(expr.value)
 	if err != nil {
 		return nil, err
 	}
-	return i.environment.assign(expr.name, value)
+	return i.assignVariable(expr.name, expr, value)
+}
+
+func (i *Interpreter) assignVariable(name Token, expr Expr, value any) (any, RuntimeError) {
+	if distance, ok := i.locals[expr]; ok {
+		return i.environment.assignAt(distance, name, value)
+	}
+	return i.globals.assign(name, value)
+}
+
+// compoundOperators maps the compound assignment operators to the binary ones
+var compoundOperators = map[TokenType]TokenType{
+	PLUS_EQUAL:  PLUS,
+	MINUS_EQUAL: MINUS,
+	STAR_EQUAL:  STAR,
+	SLASH_EQUAL: SLASH,
+	PLUS_PLUS:   PLUS,
+	MINUS_MINUS: MINUS,
+}
+
+func (i *Interpreter) VisitCompoundAssignmentExpr(expr *CompoundAssignmentExpr) (any, LoxError) {
+	operator := expr.operator
+	operator.TokenType = compoundOperators[expr.operator.TokenType]
+
+	_, updated, err := i.update(expr.target, func(current any) (any, LoxError) {
+		value, err := i.evaluate(expr.value)
+		if err != nil {
+			return nil, err
+		}
+		return i.binary(operator, current, value)
+	})
+	return updated, err
+}
+
+func (i *Interpreter) VisitIncrementExpr(expr *IncrementExpr) (any, LoxError) {
+	operator := expr.operator
+	operator.TokenType = compoundOperators[expr.operator.TokenType]
+
+	previous, updated, err := i.update(expr.target, func(current any) (any, LoxError) {
+		return i.binary(operator, current, 1.0)
+	})
+	if expr.prefix {
+		return updated, err
+	}
+	return previous, err
+}
+
+// update reads the assignment target, computes its new value and stores it. The
+// parts of the target (the object, the index) are evaluated only once.
+func (i *Interpreter) update(target Expr, compute func(any) (any, LoxError)) (any, any, LoxError) {
+	switch target := target.(type) {
+	case *VariableExpr:
+		current, err := i.lookUpVariable(target.name, target)
+		if err != nil {
+			return nil, nil, err
+		}
+		updated, err := compute(current)
+		if err != nil {
+			return nil, nil, err
+		}
+		if _, err := i.assignVariable(target.name, target, updated); err != nil {
+			return nil, nil, err
+		}
+		return current, updated, nil
+	case *GetExpr:
+		object, err := i.evaluate(target.object)
+		if err != nil {
+			return nil, nil, err
+		}
+		instance, ok := object.(*LoxInstance)
+		if !ok {
+			return nil, nil, &RuntimeErrorObj{target.name, "Only instances have fields"}
+		}
+		current, err := instance.get(target.name)
+		if err != nil {
+			return nil, nil, err
+		}
+		updated, err := compute(current)
+		if err != nil {
+			return nil, nil, err
+		}
+		instance.set(target.name, updated)
+		return current, updated, nil
+	case *IndexExpr:
+		object, err := i.evaluate(target.object)
+		if err != nil {
+			return nil, nil, err
+		}
+		index, err := i.evaluate(target.index)
+		if err != nil {
+			return nil, nil, err
+		}
+		var current any
+		switch collection := object.(type) {
+		case *LoxList:
+			current, err = collection.get(target.bracket, index)
+		case *LoxMap:
+			current, err = collection.get(target.bracket, index)
+		default:
+			return nil, nil, &RuntimeErrorObj{target.bracket, "Only lists and maps can be indexed"}
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		updated, err := compute(current)
+		if err != nil {
+			return nil, nil, err
+		}
+		switch collection := object.(type) {
+		case *LoxList:
+			_, err = collection.set(target.bracket, index, updated)
+		case *LoxMap:
+			_, err = collection.set(target.bracket, index, updated)
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		return current, updated, nil
+	}
+	// the parser only lets assignable targets through
+	return nil, nil, nil
 }
 
 func NewInterpreter() *Interpreter {
-	globals := NewEnvironment(nil)
-	globals.define("clock", ClockNativeFunction{})
-	return &Interpreter{
-		globals:     globals,
-		environment: globals,
+	builtins := NewEnvironment(nil)
+	builtins.define("clock", ClockNativeFunction{})
+	for _, native := range listNatives {
+		builtins.define(native.name, native)
+	}
+	for _, native := range mapNatives {
+		builtins.define(native.name, native)
 	}
+	for _, native := range functionalNatives {
+		builtins.define(native.name, native)
+	}
+	for _, native := range iteratorNatives {
+		builtins.define(native.name, native)
+	}
+	interpreter := &Interpreter{
+		builtins:    builtins,
+		globals:     builtins,
+		environment: builtins,
+		locals:      make(map[Expr]int),
+		tailCalls:   make(map[*ReturnStmt]bool),
+		modules:     make(map[string]*LoxModule),
+	}
+	interpreter.loadPrelude()
+
+	// user code gets globals of its own so that modules don't see them
+	interpreter.globals = NewEnvironment(builtins)
+	interpreter.environment = interpreter.globals
+	return interpreter
 }
 
 func (i *Interpreter) Interpret(statements []Stmt) {
@@ -33,14 +197,13 @@ func (i *Interpreter) Interpret(statements []Stmt) {
 		_, err := i.execute(statement)
 		if err != nil {
 			fmt.Println("Error...")
-			runtimeError(err.(RuntimeError))
+			runtimeError(err.(RuntimeError), i.script)
 		}
 	}
 }
 
-func (i *Interpreter) VisitBinaryExpr(expr BinaryExpr) (any, LoxError) {
+func (i *Interpreter) VisitBinaryExpr(expr *BinaryExpr) (any, LoxError) {
 	left, err := i.evaluate(expr.left)
-	var numbers []float64
 	if err != nil {
 		return nil, err
 	}
@@ -48,10 +211,16 @@ func (i *Interpreter) VisitBinaryExpr(expr BinaryExpr) (any, LoxError) {
 	if err != nil {
 		return nil, err
 	}
+	return i.binary(expr.operator, left, right)
+}
 
-	switch expr.operator.TokenType {
+// binary applies a binary operator to already evaluated operands
+func (i *Interpreter) binary(operator Token, left, right any) (any, LoxError) {
+	var numbers []float64
+	var err RuntimeError
+	switch operator.TokenType {
 	case MINUS:
-		if numbers, err = validateNumber(expr.operator, left, right); err != nil {
+		if numbers, err = validateNumber(operator, left, right); err != nil {
 			return nil, err
 		}
 		return numbers[0] - numbers[1], nil
@@ -68,7 +237,7 @@ func (i *Interpreter) VisitBinaryExpr(expr BinaryExpr) (any, LoxError) {
 			return left_str + right_str, nil
 		default:
 			return nil, &RuntimeErrorObj{
-				expr.operator,
+				operator,
 				"Operands must be numbers or strings",
 			}
 		}
@@ -87,38 +256,70 @@ func (i *Interpreter) VisitBinaryExpr(expr BinaryExpr) (any, LoxError) {
 			return i.multiplyString(int(right_num), left_str)
 		default:
 			return nil, &RuntimeErrorObj{
-				expr.operator,
+				operator,
 				"Cannot multiply string by string",
 			}
 		}
 	case SLASH:
-		if numbers, err = validateNumber(expr.operator, left, right); err != nil {
+		if numbers, err = validateNumber(operator, left, right); err != nil {
 			return nil, err
 		}
 		if numbers[1] == 0 {
 			return nil, &RuntimeErrorObj{
-				expr.operator,
+				operator,
 				"Division by zero.",
 			}
 		}
 		return numbers[0] / numbers[1], nil
+	case TILDE_SLASH:
+		if numbers, err = validateNumber(operator, left, right); err != nil {
+			return nil, err
+		}
+		if numbers[1] == 0 {
+			return nil, &RuntimeErrorObj{
+				operator,
+				"Division by zero.",
+			}
+		}
+		return math.Floor(numbers[0] / numbers[1]), nil
+	case PERCENT:
+		if numbers, err = validateNumber(operator, left, right); err != nil {
+			return nil, err
+		}
+		if numbers[1] == 0 {
+			return nil, &RuntimeErrorObj{
+				operator,
+				"Division by zero.",
+			}
+		}
+		// floored modulo: the result has the sign of the divisor
+		remainder := math.Mod(numbers[0], numbers[1])
+		if remainder != 0 && (remainder < 0) != (numbers[1] < 0) {
+			remainder += numbers[1]
+		}
+		return remainder, nil
+	case STAR_STAR:
+		if numbers, err = validateNumber(operator, left, right); err != nil {
+			return nil, err
+		}
+		return math.Pow(numbers[0], numbers[1]), nil
 	case GREATER:
-		if numbers, err = validateNumber(expr.operator, left, right); err != nil {
+		if numbers, err = validateNumber(operator, left, right); err != nil {
 			return nil, err
 		}
 		return numbers[0] > numbers[1], nil
 	case GREATER_EQUAL:
-		if numbers, err = validateNumber(expr.operator, left, right); err != nil {
+		if numbers, err = validateNumber(operator, left, right); err != nil {
 			return nil, err
 		}
 		return numbers[0] >= numbers[1], nil
 	case LESS:
-		if numbers, err = validateNumber(expr.operator, left, right); err != nil {
+		if numbers, err = validateNumber(operator, left, right); err != nil {
 			return nil, err
 		}
 		return numbers[0] < numbers[1], nil
 	case LESS_EQUAL:
-		if numbers, err = validateNumber(expr.operator, left, right); err != nil {
+		if numbers, err = validateNumber(operator, left, right); err != nil {
 			return nil, err
 		}
 		return numbers[0] <= numbers[1], nil
@@ -131,13 +332,39 @@ func (i *Interpreter) VisitBinaryExpr(expr BinaryExpr) (any, LoxError) {
 
 	return nil, nil
 }
-func (i *Interpreter) VisitGroupingExpr(expr GroupingExpr) (any, LoxError) {
+func (i *Interpreter) VisitCoalesceExpr(expr *CoalesceExpr) (any, LoxError) {
+	left, err := i.evaluate(expr.left)
+	if err != nil {
+		return nil, err
+	}
+	if left != nil {
+		return left, nil
+	}
+	return i.evaluate(expr.right)
+}
+
+func (i *Interpreter) VisitConditionalExpr(expr *ConditionalExpr) (any, LoxError) {
+	value, err := i.evaluate(expr.condition)
+	if err != nil {
+		return nil, err
+	}
+	condition, err := i.isTruthy(value)
+	if err != nil {
+		return nil, err
+	}
+	if condition {
+		return i.evaluate(expr.thenBranch)
+	}
+	return i.evaluate(expr.elseBranch)
+}
+
+func (i *Interpreter) VisitGroupingExpr(expr *GroupingExpr) (any, LoxError) {
 	return i.evaluate(expr.expression)
 }
-func (i *Interpreter) VisitLiteralExpr(expr LiteralExpr) (any, LoxError) {
+func (i *Interpreter) VisitLiteralExpr(expr *LiteralExpr) (any, LoxError) {
 	return expr.value, nil
 }
-func (i *Interpreter) VisitLogicalExpr(expr LogicalExpr) (any, LoxError) {
+func (i *Interpreter) VisitLogicalExpr(expr *LogicalExpr) (any, LoxError) {
 	left, err := i.evaluate(expr.left)
 	if err != nil {
 		return nil, err
@@ -162,12 +389,19 @@ func (i *Interpreter) VisitLogicalExpr(expr LogicalExpr) (any, LoxError) {
 	}
 	return right, nil
 }
-func (i *Interpreter) VisitUnaryExpr(expr UnaryExpr) (any, LoxError) {
-	right, _ := i.evaluate(expr.right)
+func (i *Interpreter) VisitUnaryExpr(expr *UnaryExpr) (any, LoxError) {
+	right, err := i.evaluate(expr.right)
+	if err != nil {
+		return nil, err
+	}
 
 	switch expr.operator.TokenType {
 	case MINUS:
-		return -right.(float64), nil
+		numbers, err := validateNumber(expr.operator, right)
+		if err != nil {
+			return nil, err
+		}
+		return -numbers[0], nil
 	case BANG:
 		value, err := i.isTruthy(right)
 
@@ -177,19 +411,30 @@ func (i *Interpreter) VisitUnaryExpr(expr UnaryExpr) (any, LoxError) {
 	// unreachable
 	return nil, &RuntimeErrorObj{expr.operator, "Unexpected unary operator"}
 }
-func (i *Interpreter) VisitVariableExpr(expr VariableExpr) (any, LoxError) {
-	return i.environment.get(expr.name)
+func (i *Interpreter) VisitVariableExpr(expr *VariableExpr) (any, LoxError) {
+	return i.lookUpVariable(expr.name, expr)
+}
+
+func (i *Interpreter) lookUpVariable(name Token, expr Expr) (any, RuntimeError) {
+	if distance, ok := i.locals[expr]; ok {
+		return i.environment.getAt(distance, name)
+	}
+	return i.globals.get(name)
+}
+
+func (i *Interpreter) resolve(expr Expr, depth int) {
+	i.locals[expr] = depth
 }
 
 // ------------------------------------------------------------------------------------------
-func (i *Interpreter) VisitExpressionStmt(stmt ExpressionStmt) (any, LoxError) {
+func (i *Interpreter) VisitExpressionStmt(stmt *ExpressionStmt) (any, LoxError) {
 	v, err := i.evaluate(stmt.expression)
 	if err != nil {
 		return nil, err
 	}
 	return v, nil
 }
-func (i *Interpreter) VisitIfStmt(stmt IfStmt) (any, LoxError) {
+func (i *Interpreter) VisitIfStmt(stmt *IfStmt) (any, LoxError) {
 	val, err := i.evaluate(stmt.condition)
 	if err != nil {
 		return nil, err
@@ -207,7 +452,7 @@ func (i *Interpreter) VisitIfStmt(stmt IfStmt) (any, LoxError) {
 	}
 	return nil, nil
 }
-func (i *Interpreter) VisitWhileStmt(stmt WhileStmt) (any, LoxError) {
+func (i *Interpreter) VisitWhileStmt(stmt *WhileStmt) (any, LoxError) {
 	for {
 		val, err := i.evaluate(stmt.condition)
 		if err != nil {
@@ -221,12 +466,71 @@ func (i *Interpreter) VisitWhileStmt(stmt WhileStmt) (any, LoxError) {
 			return nil, nil
 		}
 		_, err = i.execute(stmt.body)
+		if err != nil {
+			switch err.(type) {
+			case *BreakObj:
+				return nil, nil
+			case *ContinueObj:
+				// go on with the increment
+			default:
+				return nil, err
+			}
+		}
+		if stmt.increment != nil {
+			if _, err := i.evaluate(stmt.increment); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+func (i *Interpreter) VisitForInStmt(stmt *ForInStmt) (result any, err LoxError) {
+	iterable, err := i.evaluate(stmt.iterable)
+	if err != nil {
+		return nil, err
+	}
+	iterator, err := i.iterator(iterable, stmt.keyword)
+	if err != nil {
+		return nil, err
+	}
+	// however the loop is left, a generator it goes through is closed, unless
+	// the loop is in the body of an abandoned generator which must not run any
+	// Lox code anymore
+	if closer, ok := iterator.(iteratorCloser); ok {
+		defer func() {
+			if i.generator != nil && i.generator.abandoned {
+				return
+			}
+			if closeErr := closer.close(); err == nil {
+				err = closeErr
+			}
+		}()
+	}
+
+	for {
+		value, ok, err := iterator.next(i)
 		if err != nil {
 			return nil, err
 		}
+		if !ok {
+			return nil, nil
+		}
+		environment := NewEnvironment(i.environment)
+		environment.define(stmt.name.Lexeme, value)
+		_, err = i.executeBlock([]Stmt{stmt.body}, environment)
+		if err != nil {
+			switch err.(type) {
+			case *BreakObj:
+				return nil, nil
+			case *ContinueObj:
+				// go on with the next value
+			default:
+				return nil, err
+			}
+		}
 	}
 }
-func (i *Interpreter) VisitPrintStmt(stmt PrintStmt) (any, LoxError) {
+
+func (i *Interpreter) VisitPrintStmt(stmt *PrintStmt) (any, LoxError) {
 	v, err := i.evaluate(stmt.expression)
 	if err != nil {
 		return nil, err
@@ -234,8 +538,8 @@ func (i *Interpreter) VisitPrintStmt(stmt PrintStmt) (any, LoxError) {
 	fmt.Println(i.stringify(v))
 	return v, nil
 }
-func (i *Interpreter) VisitVarStmt(stmt VarStmt) (any, LoxError) {
-	var value any
+func (i *Interpreter) VisitVarStmt(stmt *VarStmt) (any, LoxError) {
+	var value any = uninitialized
 	var err LoxError
 	if stmt.initializer != nil {
 		value, err = i.evaluate(stmt.initializer)
@@ -243,7 +547,20 @@ func (i *Interpreter) VisitVarStmt(stmt VarStmt) (any, LoxError) {
 			return nil, err
 		}
 	}
-	i.environment.define(stmt.name.Lexeme, value)
+	if err := i.environment.declare(stmt.name, value); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+func (i *Interpreter) VisitConstStmt(stmt *ConstStmt) (any, LoxError) {
+	value, err := i.evaluate(stmt.initializer)
+	if err != nil {
+		return nil, err
+	}
+	if err := i.environment.declareConstant(stmt.name, value); err != nil {
+		return nil, err
+	}
 	return nil, nil
 }
 
@@ -274,6 +591,10 @@ func (i *Interpreter) isEqual(a, b any) (bool, LoxError) {
 	return a == b, nil
 }
 func (i *Interpreter) stringify(object any) string {
+	return stringify(object)
+}
+
+func stringify(object any) string {
 	if object == nil {
 		return "nil"
 	}
@@ -321,22 +642,71 @@ func (i *Interpreter) execute(stmt Stmt) (any, LoxError) {
 	return stmt.Accept(i)
 }
 
-func (i *Interpreter) VisitBlockStmt(stmt BlockStmt) (any, LoxError) {
+func (i *Interpreter) VisitBlockStmt(stmt *BlockStmt) (any, LoxError) {
 	return i.executeBlock(stmt.statements, NewEnvironment(i.environment))
 }
 
-func (i *Interpreter) descope() {
-	// leave the current lexical scope
-	if i.environment.Enclosing == nil {
-		return
+// VisitMatchStmt runs the first arm whose pattern matches the subject and whose
+// guard holds, the variables bound by the pattern are visible in the guard and
+// in the body
+func (i *Interpreter) VisitMatchStmt(stmt *MatchStmt) (any, LoxError) {
+	subject, err := i.evaluate(stmt.subject)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, arm := range stmt.arms {
+		matcher := newPatternMatcher(i)
+		matched, err := matcher.match(arm.pattern, subject)
+		if err != nil {
+			return nil, err
+		}
+		if !matched {
+			continue
+		}
+
+		environment := NewEnvironment(i.environment)
+		for name, value := range matcher.bindings {
+			environment.define(name, value)
+		}
+		if arm.guard != nil {
+			value, err := i.evaluateIn(arm.guard, environment)
+			if err != nil {
+				return nil, err
+			}
+			holds, err := i.isTruthy(value)
+			if err != nil {
+				return nil, err
+			}
+			if !holds {
+				continue
+			}
+		}
+		return i.executeBlock([]Stmt{arm.body}, environment)
 	}
+	return nil, nil
+}
+
+// evaluateIn evaluates an expression that is resolved in the scope of the
+// environment rather than the current one
+func (i *Interpreter) evaluateIn(expr Expr, environment *Environment) (any, LoxError) {
+	previous := i.environment
+	i.environment = environment
+	defer i.restore(previous)
 
-	i.environment = i.environment.Enclosing
+	return i.evaluate(expr)
+}
+
+func (i *Interpreter) restore(previous *Environment) {
+	// leave the current lexical scope; the block's environment does not have to be
+	// enclosed by the previous one (e.g. a function called through its closure)
+	i.environment = previous
 }
 
 func (i *Interpreter) executeBlock(statements []Stmt, environment *Environment) (any, RuntimeError) {
+	previous := i.environment
 	i.environment = environment
-	defer i.descope()
+	defer i.restore(previous)
 
 	for _, stmt := range statements {
 		value, err := i.execute(stmt)
@@ -346,39 +716,258 @@ func (i *Interpreter) executeBlock(statements []Stmt, environment *Environment)
 	}
 	return nil, nil
 }
-func (i *Interpreter) VisitCallExpr(expr CallExpr) (any, LoxError) {
-	callee, err := i.evaluate(expr.callee)
+func (i *Interpreter) VisitCallExpr(expr *CallExpr) (any, LoxError) {
+	function, arguments, err := i.callArguments(expr)
 	if err != nil {
 		return nil, err
 	}
+	return i.call(expr.paren, function, arguments)
+}
+
+// callArguments evaluates the callee and the arguments of the call, the named
+// arguments are put in the place of their parameters
+func (i *Interpreter) callArguments(expr *CallExpr) (LoxCallable, []any, LoxError) {
+	callee, err := i.evaluate(expr.callee)
+	if err != nil {
+		return nil, nil, err
+	}
 
 	var arguments []any
 	for _, arg := range expr.arguments {
 		arg_evaled, err := i.evaluate(arg)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		arguments = append(arguments, arg_evaled)
 	}
 
 	function, ok := callee.(LoxCallable)
 	if !ok {
-		return nil, &RuntimeErrorObj{expr.paren, "Can only call functions and classes"}
+		return nil, nil, &RuntimeErrorObj{expr.paren, "Can only call functions and classes"}
 	}
 
-	if function.Arity() != len(arguments) {
-		msg := fmt.Sprintf("Expected %d arguments but got %d", function.Arity(), len(arguments))
-		return nil, &RuntimeErrorObj{expr.paren, msg}
+	if expr.names != nil {
+		arguments, err = namedArguments(function, expr, arguments)
+		if err != nil {
+			return nil, nil, err
+		}
 	}
-	return function.Call(i, arguments)
+	return function, arguments, nil
 }
 
-func (i *Interpreter) VisitFunctionStmt(stmt FunctionStmt) (any, LoxError) {
-	function := NewLoxFunction(stmt)
-	i.environment.define(stmt.name.Lexeme, function)
+// checkArguments tells whether the function can be called with the arguments
+func checkArguments(token Token, function LoxCallable, arguments []any) LoxError {
+	if !acceptsArguments(function, len(arguments)) {
+		msg := fmt.Sprintf("Expected %s arguments but got %d", describeArity(function), len(arguments))
+		return &RuntimeErrorObj{token, msg}
+	}
+	return nil
+}
+
+// call calls the function after checking that it accepts the arguments, so
+// that no caller can get a function to run with missing parameters. The
+// errors of native functions are reported at the token.
+func (i *Interpreter) call(token Token, function LoxCallable, arguments []any) (any, LoxError) {
+	if err := checkArguments(token, function, arguments); err != nil {
+		return nil, err
+	}
+	result, err := function.Call(i, arguments)
+	if nativeErr, ok := err.(*NativeErrorObj); ok {
+		return nil, &RuntimeErrorObj{token, nativeErr.message}
+	}
+	return result, err
+}
+
+func (i *Interpreter) VisitFunctionStmt(stmt *FunctionStmt) (any, LoxError) {
+	function := NewLoxFunction(stmt, i.environment, i.globals, false)
+	if err := i.environment.declare(stmt.name, function); err != nil {
+		return nil, err
+	}
 	return nil, nil
 }
 
+func (i *Interpreter) VisitClassStmt(stmt *ClassStmt) (any, LoxError) {
+	var superclass *LoxClass
+	if stmt.superclass != nil {
+		value, err := i.evaluate(stmt.superclass)
+		if err != nil {
+			return nil, err
+		}
+		class, ok := value.(*LoxClass)
+		if !ok {
+			return nil, &RuntimeErrorObj{stmt.superclass.name, "Superclass must be a class"}
+		}
+		superclass = class
+	}
+
+	if err := i.environment.declare(stmt.name, nil); err != nil {
+		return nil, err
+	}
+
+	if superclass != nil {
+		i.environment = NewEnvironment(i.environment)
+		i.environment.define("super", superclass)
+	}
+
+	methods := make(map[string]*LoxFunction)
+	for _, method := range stmt.methods {
+		methods[method.name.Lexeme] = NewLoxFunction(method, i.environment, i.globals, method.name.Lexeme == "init")
+	}
+
+	class := NewLoxClass(stmt.name.Lexeme, superclass, methods)
+
+	if superclass != nil {
+		i.environment = i.environment.Enclosing
+	}
+	i.environment.define(stmt.name.Lexeme, class)
+	return nil, nil
+}
+
+func (i *Interpreter) VisitGetExpr(expr *GetExpr) (any, LoxError) {
+	object, err := i.evaluate(expr.object)
+	if err != nil {
+		return nil, err
+	}
+	return i.getProperty(object, expr.name)
+}
+
+func (i *Interpreter) getProperty(object any, name Token) (any, LoxError) {
+	switch object := object.(type) {
+	case *LoxInstance:
+		return object.get(name)
+	case *LoxModule:
+		return object.get(name)
+	case *LoxGenerator:
+		return object.get(i, name)
+	case string:
+		return stringProperty(object, name)
+	}
+	return nil, &RuntimeErrorObj{name, "Only instances have properties"}
+}
+
+func (i *Interpreter) VisitInterpolationExpr(expr *InterpolationExpr) (any, LoxError) {
+	var sb strings.Builder
+	for _, part := range expr.parts {
+		value, err := i.evaluate(part)
+		if err != nil {
+			return nil, err
+		}
+		sb.WriteString(i.stringify(value))
+	}
+	return sb.String(), nil
+}
+
+func (i *Interpreter) VisitLambdaExpr(expr *LambdaExpr) (any, LoxError) {
+	return NewLoxFunction(expr.function, i.environment, i.globals, false), nil
+}
+
+func (i *Interpreter) VisitListExpr(expr *ListExpr) (any, LoxError) {
+	elements := make([]any, 0, len(expr.elements))
+	for _, element := range expr.elements {
+		value, err := i.evaluate(element)
+		if err != nil {
+			return nil, err
+		}
+		elements = append(elements, value)
+	}
+	return NewLoxList(elements), nil
+}
+
+func (i *Interpreter) VisitMapExpr(expr *MapExpr) (any, LoxError) {
+	m := NewLoxMap()
+	for j := range expr.keys {
+		key, err := i.evaluate(expr.keys[j])
+		if err != nil {
+			return nil, err
+		}
+		value, err := i.evaluate(expr.values[j])
+		if err != nil {
+			return nil, err
+		}
+		if _, err := m.set(expr.brace, key, value); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+func (i *Interpreter) VisitIndexExpr(expr *IndexExpr) (any, LoxError) {
+	object, err := i.evaluate(expr.object)
+	if err != nil {
+		return nil, err
+	}
+	index, err := i.evaluate(expr.index)
+	if err != nil {
+		return nil, err
+	}
+
+	switch collection := object.(type) {
+	case *LoxList:
+		return collection.get(expr.bracket, index)
+	case *LoxMap:
+		return collection.get(expr.bracket, index)
+	}
+	return nil, &RuntimeErrorObj{expr.bracket, "Only lists and maps can be indexed"}
+}
+
+func (i *Interpreter) VisitIndexSetExpr(expr *IndexSetExpr) (any, LoxError) {
+	object, err := i.evaluate(expr.object)
+	if err != nil {
+		return nil, err
+	}
+	index, err := i.evaluate(expr.index)
+	if err != nil {
+		return nil, err
+	}
+	value, err := i.evaluate(expr.value)
+	if err != nil {
+		return nil, err
+	}
+
+	switch collection := object.(type) {
+	case *LoxList:
+		return collection.set(expr.bracket, index, value)
+	case *LoxMap:
+		return collection.set(expr.bracket, index, value)
+	}
+	return nil, &RuntimeErrorObj{expr.bracket, "Only lists and maps can be indexed"}
+}
+
+func (i *Interpreter) VisitSetExpr(expr *SetExpr) (any, LoxError) {
+	object, err := i.evaluate(expr.object)
+	if err != nil {
+		return nil, err
+	}
+	instance, ok := object.(*LoxInstance)
+	if !ok {
+		return nil, &RuntimeErrorObj{expr.name, "Only instances have fields"}
+	}
+
+	value, err := i.evaluate(expr.value)
+	if err != nil {
+		return nil, err
+	}
+	instance.set(expr.name, value)
+	return value, nil
+}
+
+func (i *Interpreter) VisitSuperExpr(expr *SuperExpr) (any, LoxError) {
+	distance := i.locals[expr]
+	superclass := i.environment.ancestor(distance).Values["super"].(*LoxClass)
+	// 'this' is always bound in the environment right inside the one holding 'super'
+	instance := i.environment.ancestor(distance - 1).Values["this"].(*LoxInstance)
+
+	method, ok := superclass.findMethod(expr.method.Lexeme)
+	if !ok {
+		return nil, &RuntimeErrorObj{expr.method, "Undefined property '" + expr.method.Lexeme + "'"}
+	}
+	return method.bind(instance), nil
+}
+
+func (i *Interpreter) VisitThisExpr(expr *ThisExpr) (any, LoxError) {
+	return i.lookUpVariable(expr.keyword, expr)
+}
+
 type ReturnObj struct {
 	RuntimeErrorObj
 	value any
@@ -388,7 +977,35 @@ func (r ReturnObj) GetValue() any {
 	return r.value
 }
 
-func (i *Interpreter) VisitReturnStmt(stmt ReturnStmt) (any, LoxError) {
+// TailCallObj asks the function call it unwinds to to run another function
+// in its place, see LoxFunction.Call
+type TailCallObj struct {
+	RuntimeErrorObj
+	function  *LoxFunction
+	arguments []any
+}
+
+func (i *Interpreter) VisitReturnStmt(stmt *ReturnStmt) (any, LoxError) {
+	if i.tailCalls[stmt] {
+		call := stmt.value.(*CallExpr)
+		function, arguments, err := i.callArguments(call)
+		if err != nil {
+			return nil, err
+		}
+		// generators and initializers are not called like other functions
+		if lf, ok := function.(*LoxFunction); ok && !lf.declaration.generator && !lf.isInitializer {
+			if err := checkArguments(call.paren, function, arguments); err != nil {
+				return nil, err
+			}
+			return nil, &TailCallObj{RuntimeErrorObj{stmt.keyword, "return"}, lf, arguments}
+		}
+		value, err := i.call(call.paren, function, arguments)
+		if err != nil {
+			return nil, err
+		}
+		return nil, &ReturnObj{RuntimeErrorObj{stmt.keyword, "return"}, value}
+	}
+
 	var value any
 	var err LoxError
 	if stmt.value!=nil {
@@ -401,4 +1018,82 @@ func (i *Interpreter) VisitReturnStmt(stmt ReturnStmt) (any, LoxError) {
 		RuntimeErrorObj{stmt.keyword, "return"},
 		value,
 	}
-}
\ No newline at end of file
+}
+
+// break and continue unwind the statements up to the enclosing loop the same
+// way a return does up to the function call
+type BreakObj struct {
+	RuntimeErrorObj
+}
+
+type ContinueObj struct {
+	RuntimeErrorObj
+}
+
+func (i *Interpreter) VisitBreakStmt(stmt *BreakStmt) (any, LoxError) {
+	return nil, &BreakObj{RuntimeErrorObj{stmt.keyword, "break"}}
+}
+
+func (i *Interpreter) VisitContinueStmt(stmt *ContinueStmt) (any, LoxError) {
+	return nil, &ContinueObj{RuntimeErrorObj{stmt.keyword, "continue"}}
+}
+
+// ThrowObj carries a thrown value up to the nearest enclosing try statement.
+type ThrowObj struct {
+	RuntimeErrorObj
+	value any
+}
+
+func (i *Interpreter) VisitThrowStmt(stmt *ThrowStmt) (any, LoxError) {
+	value, err := i.evaluate(stmt.value)
+	if err != nil {
+		return nil, err
+	}
+
+	message := "Uncaught exception: " + i.stringify(value)
+	if instance, ok := value.(*LoxInstance); ok && instance.class.isSubclassOf(i.errorClass) {
+		if _, ok := instance.fields["line"]; !ok {
+			instance.set(Token{Lexeme: "line"}, float64(stmt.keyword.Line))
+		}
+		if errorMessage, ok := instance.fields["message"]; ok {
+			message = "Uncaught " + instance.class.name + ": " + i.stringify(errorMessage)
+		}
+	}
+	return nil, &ThrowObj{RuntimeErrorObj{stmt.keyword, message}, value}
+}
+
+func (i *Interpreter) VisitTryStmt(stmt *TryStmt) (any, LoxError) {
+	_, err := i.executeBlock(stmt.tryBlock, NewEnvironment(i.environment))
+
+	if err != nil && stmt.catchName.Lexeme != "" {
+		if value, ok := i.caughtValue(err); ok {
+			environment := NewEnvironment(i.environment)
+			environment.define(stmt.catchName.Lexeme, value)
+			_, err = i.executeBlock(stmt.catchBlock, environment)
+		}
+	}
+
+	// finally runs no matter how the try (or catch) block was left; if the
+	// finally block itself returns, breaks or throws, that wins
+	if stmt.finallyBlock != nil {
+		if _, finallyErr := i.executeBlock(stmt.finallyBlock, NewEnvironment(i.environment)); finallyErr != nil {
+			return nil, finallyErr
+		}
+	}
+	return nil, err
+}
+
+// caughtValue turns an error into the value bound by a catch clause. Return,
+// break and continue are not errors and can't be caught.
+func (i *Interpreter) caughtValue(err LoxError) (any, bool) {
+	switch err := err.(type) {
+	case *ThrowObj:
+		return err.value, true
+	case *RuntimeErrorObj:
+		instance := NewLoxInstance(i.errorClass)
+		instance.set(Token{Lexeme: "message"}, err.message)
+		instance.set(Token{Lexeme: "line"}, float64(err.token.Line))
+		return instance, true
+	}
+	return nil, false
+}
@@ -0,0 +1,31 @@
+package lox
+
+import "testing"
+
+// interpretLines runs every line with a Resolver of its own, like the REPL
+func interpretLines(interpreter *Interpreter, lines ...string) {
+	for _, line := range lines {
+		scanner := NewScanner(line)
+		statements := NewParser(scanner.ScanTokens()).Parse()
+		NewResolver(interpreter).Resolve(statements)
+		interpreter.Interpret(statements)
+	}
+}
+
+func TestConstantRedeclaredOnLaterLine(t *testing.T) {
+	defer func() { HadRuntimeError = false }()
+
+	for _, declaration := range []string{"var PI = 4;", "fun PI() {}", "class PI {}", "const PI = 4;"} {
+		HadRuntimeError = false
+		interpreter := NewInterpreter()
+		interpretLines(interpreter, "const PI = 3;", declaration)
+		if !HadRuntimeError {
+			t.Errorf("failed: %q redeclared the constant", declaration)
+		}
+		interpretLines(interpreter, "PI = 5;")
+		value, _ := interpreter.globals.get(Token{TokenType: IDENTIFIER, Lexeme: "PI"})
+		if value != 3.0 {
+			t.Errorf("failed: constant is %v after %q", value, declaration)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package lox
+
+import (
+	"fmt"
+	"math"
+)
+
+// loxIterator produces the values a for-in loop goes through
+type loxIterator interface {
+	// next returns the next value, ok is false when there are no more values
+	next(i *Interpreter) (value any, ok bool, err LoxError)
+}
+
+// iteratorCloser is an iterator to close when a loop is left before it ends
+type iteratorCloser interface {
+	close() LoxError
+}
+
+// iterator returns an iterator over the value: the characters of a string, the
+// elements of a list, the keys of a map, the numbers of a range, the values of
+// a generator, or what the iterator() method of an instance returns
+func (i *Interpreter) iterator(value any, token Token) (loxIterator, LoxError) {
+	switch value := value.(type) {
+	case string:
+		return &stringIterator{runes: []rune(value)}, nil
+	case *LoxList:
+		return &listIterator{list: value}, nil
+	case *LoxMap:
+		keys := make([]any, len(value.keys))
+		copy(keys, value.keys)
+		return &listIterator{list: NewLoxList(keys)}, nil
+	case *LoxRange:
+		return &rangeIterator{current: value.start, end: value.end, step: value.step}, nil
+	case *LoxGenerator:
+		return &generatorIterator{generator: value, token: token}, nil
+	case *LoxInstance:
+		if method, ok := value.class.findMethod("iterator"); ok {
+			iterator, err := i.call(token, method.bind(value), nil)
+			if err != nil {
+				return nil, err
+			}
+			if generator, ok := iterator.(*LoxGenerator); ok {
+				return &generatorIterator{generator: generator, token: token}, nil
+			}
+			return &protocolIterator{object: iterator, token: token}, nil
+		}
+	}
+	return nil, &RuntimeErrorObj{token, "Can only iterate over strings, lists, maps, ranges, generators and objects with an iterator() method"}
+}
+
+type stringIterator struct {
+	runes []rune
+	index int
+}
+
+func (it *stringIterator) next(i *Interpreter) (any, bool, LoxError) {
+	if it.index >= len(it.runes) {
+		return nil, false, nil
+	}
+	it.index++
+	return string(it.runes[it.index-1]), true, nil
+}
+
+// listIterator sees the elements appended to the list while iterating
+type listIterator struct {
+	list  *LoxList
+	index int
+}
+
+func (it *listIterator) next(i *Interpreter) (any, bool, LoxError) {
+	if it.index >= len(it.list.elements) {
+		return nil, false, nil
+	}
+	it.index++
+	return it.list.elements[it.index-1], true, nil
+}
+
+type rangeIterator struct {
+	current, end, step float64
+}
+
+func (it *rangeIterator) next(i *Interpreter) (any, bool, LoxError) {
+	if (it.step > 0 && it.current >= it.end) || (it.step < 0 && it.current <= it.end) {
+		return nil, false, nil
+	}
+	value := it.current
+	it.current += it.step
+	return value, true, nil
+}
+
+type generatorIterator struct {
+	generator *LoxGenerator
+	token     Token
+}
+
+func (it *generatorIterator) next(i *Interpreter) (any, bool, LoxError) {
+	if err := it.generator.advance(i, it.token); err != nil {
+		return nil, false, err
+	}
+	if it.generator.finished {
+		return nil, false, nil
+	}
+	value, err := it.generator.next(i, it.token)
+	return value, true, err
+}
+
+// close closes the generator, a loop breaking out of it leaves nothing running
+func (it *generatorIterator) close() LoxError {
+	return it.generator.close(it.token)
+}
+
+// protocolIterator goes through an object with a 'done' property (or method)
+// and a next() method
+type protocolIterator struct {
+	object any
+	// the 'for' of the loop, for the position of errors
+	token Token
+}
+
+func (it *protocolIterator) next(i *Interpreter) (any, bool, LoxError) {
+	done, err := it.property(i, "done")
+	if err != nil {
+		return nil, false, err
+	}
+	isDone, err := i.isTruthy(done)
+	if err != nil || isDone {
+		return nil, false, err
+	}
+	value, err := it.property(i, "next")
+	return value, true, err
+}
+
+// property returns the value of the property, calling it when it is a method
+func (it *protocolIterator) property(i *Interpreter, name string) (any, LoxError) {
+	token := Token{TokenType: IDENTIFIER, Lexeme: name, Line: it.token.Line, File: it.token.File}
+	value, err := i.getProperty(it.object, token)
+	if err != nil {
+		return nil, err
+	}
+	function, ok := value.(LoxCallable)
+	if !ok {
+		if name == "next" {
+			return nil, &RuntimeErrorObj{token, "Iterator 'next' must be a method"}
+		}
+		return value, nil
+	}
+	return i.call(token, function, nil)
+}
+
+// LoxRange is the lazy sequence of numbers range() returns
+type LoxRange struct {
+	start, end, step float64
+}
+
+func (r *LoxRange) String() string {
+	return fmt.Sprintf("range(%v, %v, %v)", r.start, r.end, r.step)
+}
+
+var iteratorNatives = []*NativeFunction{
+	NewNativeFunctionRange("range", 1, 3, nativeRange),
+}
+
+// range(end), range(start, end) or range(start, end, step), the end is not
+// included
+func nativeRange(i *Interpreter, arguments []any) (any, LoxError) {
+	bounds := []float64{0, 0, 1}
+	for j, argument := range arguments {
+		number, ok := argument.(float64)
+		if !ok || math.IsNaN(number) {
+			return nil, nativeError("Range bounds must be numbers")
+		}
+		bounds[j] = number
+	}
+	if len(arguments) == 1 {
+		bounds[0], bounds[1] = 0, bounds[0]
+	}
+	if bounds[2] == 0 {
+		return nil, nativeError("Range step can't be zero")
+	}
+	return &LoxRange{start: bounds[0], end: bounds[1], step: bounds[2]}, nil
+}
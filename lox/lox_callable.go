@@ -6,37 +6,220 @@ import (
 
 type LoxCallable interface {
 	Call(interpreter *Interpreter, arguments []any) (any, LoxError)
-	Arity() int
+	// Arity returns the minimum and maximum number of arguments, the maximum
+	// is unlimitedArity for callables taking any number of extra arguments
+	Arity() (int, int)
 }
 
+const unlimitedArity = -1
+
+// acceptsArguments tells whether the callable can be called with count arguments
+func acceptsArguments(function LoxCallable, count int) bool {
+	min, max := function.Arity()
+	return count >= min && (max == unlimitedArity || count <= max)
+}
+
+// describeArity returns the allowed number of arguments as "2", "1 to 3" or "at least 1"
+func describeArity(function LoxCallable) string {
+	min, max := function.Arity()
+	switch {
+	case max == unlimitedArity:
+		return fmt.Sprintf("at least %d", min)
+	case min == max:
+		return fmt.Sprintf("%d", min)
+	}
+	return fmt.Sprintf("%d to %d", min, max)
+}
+
+// missingArgument stands for the arguments left out of a call with named
+// arguments, the parameter gets its default value instead
+type missingArgumentValue struct{}
+
+var missingArgument = missingArgumentValue{}
+
 type LoxFunction struct {
-	declaration FunctionStmt
+	declaration   *FunctionStmt
+	closure       *Environment
+	// globals of the module the function was declared in
+	globals       *Environment
+	isInitializer bool
 }
 
-func NewLoxFunction(declaration FunctionStmt) *LoxFunction {
-	return &LoxFunction{declaration: declaration}
+func NewLoxFunction(declaration *FunctionStmt, closure *Environment, globals *Environment, isInitializer bool) *LoxFunction {
+	return &LoxFunction{
+		declaration:   declaration,
+		closure:       closure,
+		globals:       globals,
+		isInitializer: isInitializer,
+	}
 }
 
-func (lf *LoxFunction) Arity() int {
-	return len(lf.declaration.params)
+// bind returns a copy of the method whose closure has 'this' set to the instance
+func (lf *LoxFunction) bind(instance *LoxInstance) *LoxFunction {
+	environment := NewEnvironment(lf.closure)
+	environment.define("this", instance)
+	return NewLoxFunction(lf.declaration, environment, lf.globals, lf.isInitializer)
+}
+
+func (lf *LoxFunction) Arity() (int, int) {
+	// the parameters with a default value come after the required ones
+	required := 0
+	for required < len(lf.declaration.params) && lf.declaration.defaults[required] == nil {
+		required++
+	}
+	if lf.declaration.rest.Lexeme != "" {
+		return required, unlimitedArity
+	}
+	return required, len(lf.declaration.params)
 }
 
 func (lf *LoxFunction) Call(i *Interpreter, arguments []any) (any, LoxError) {
-	environment := NewEnvironment(i.environment)
-	for j := 0; j < lf.Arity(); j++ {
-		environment.define(lf.declaration.params[j].Lexeme, arguments[j])
+	// a function called in a tail position (return f(...)) is run here, after
+	// the calling one has returned, instead of inside of it; this keeps the Go
+	// stack from growing with the depth of the recursion
+	function := lf
+	for {
+		value, err := function.run(i, arguments)
+		tailCall, ok := err.(*TailCallObj)
+		if !ok {
+			return value, err
+		}
+		function, arguments = tailCall.function, tailCall.arguments
 	}
+}
+
+// run runs the body of the function, a tail call at its end is returned as a
+// *TailCallObj for Call to make
+func (lf *LoxFunction) run(i *Interpreter, arguments []any) (any, LoxError) {
+	// globals are looked up in the function's module, not the caller's one
+	previousGlobals := i.globals
+	i.globals = lf.globals
+	defer func() { i.globals = previousGlobals }()
+
+	// the function body sees the scope it was declared in, not the caller's one
+	environment := NewEnvironment(lf.closure)
+	if err := lf.bindParameters(i, environment, arguments); err != nil {
+		return nil, err
+	}
+	// the body of a generator runs only when values are asked for
+	if lf.declaration.generator {
+		return NewLoxGenerator(lf, environment), nil
+	}
+
 	_, err := i.executeBlock(lf.declaration.body, environment)
 	if err!=nil {
 		return_value, ok := err.(*ReturnObj)
-		if ok {
+		if !ok {
+			return nil, err
+		}
+		if !lf.isInitializer {
 			return return_value.GetValue(), nil
 		}
-		return nil, err
+	}
+	// an initializer always returns the instance, even when called directly
+	if lf.isInitializer {
+		return lf.closure.Values["this"], nil
 	}
 	return nil, nil
 }
 
-func (lf *LoxFunction) Stringer() string {
-	return fmt.Sprintf("<fn %s >", lf.declaration.name.Lexeme)
+// bindParameters defines the parameters in the environment of a call. Default
+// values are evaluated at every call, in that environment, so they can refer to
+// the parameters before them.
+func (lf *LoxFunction) bindParameters(i *Interpreter, environment *Environment, arguments []any) LoxError {
+	declaration := lf.declaration
+	for j, param := range declaration.params {
+		var value any = missingArgument
+		if j < len(arguments) {
+			value = arguments[j]
+		}
+		if value == missingArgument {
+			var err LoxError
+			value, err = i.evaluateIn(declaration.defaults[j], environment)
+			if err != nil {
+				return err
+			}
+		}
+		environment.define(param.Lexeme, value)
+	}
+
+	if declaration.rest.Lexeme != "" {
+		extra := []any{}
+		if len(arguments) > len(declaration.params) {
+			extra = append(extra, arguments[len(declaration.params):]...)
+		}
+		environment.define(declaration.rest.Lexeme, NewLoxList(extra))
+	}
+	return nil
+}
+
+// namedArguments orders the arguments of a call with named arguments by the
+// parameters of the called function, leaving missingArgument where a parameter
+// is to get its default value
+func namedArguments(function LoxCallable, call *CallExpr, values []any) ([]any, RuntimeError) {
+	var declaration *FunctionStmt
+	switch function := function.(type) {
+	case *LoxFunction:
+		declaration = function.declaration
+	case *LoxClass:
+		if initializer, ok := function.findMethod("init"); ok {
+			declaration = initializer.declaration
+		}
+	}
+
+	positional := 0
+	for positional < len(call.names) && call.names[positional].Lexeme == "" {
+		positional++
+	}
+	if declaration == nil {
+		return nil, &RuntimeErrorObj{call.names[positional], fmt.Sprintf("%s doesn't take named arguments", stringify(function))}
+	}
+
+	params := declaration.params
+	if positional > len(params) && declaration.rest.Lexeme == "" {
+		msg := fmt.Sprintf("Expected %s arguments but got %d", describeArity(function), len(values))
+		return nil, &RuntimeErrorObj{call.paren, msg}
+	}
+
+	arguments := make([]any, len(params))
+	for j := range arguments {
+		arguments[j] = missingArgument
+	}
+	copy(arguments, values[:positional])
+
+	for j := positional; j < len(values); j++ {
+		name := call.names[j]
+		index := -1
+		for k, param := range params {
+			if param.Lexeme == name.Lexeme {
+				index = k
+			}
+		}
+		if index < 0 {
+			return nil, &RuntimeErrorObj{name, fmt.Sprintf("Unexpected argument '%s'", name.Lexeme)}
+		}
+		if arguments[index] != missingArgument {
+			return nil, &RuntimeErrorObj{name, fmt.Sprintf("Got multiple values for argument '%s'", name.Lexeme)}
+		}
+		arguments[index] = values[j]
+	}
+
+	for j, param := range params {
+		if arguments[j] == missingArgument && declaration.defaults[j] == nil {
+			return nil, &RuntimeErrorObj{call.paren, fmt.Sprintf("Missing argument '%s'", param.Lexeme)}
+		}
+	}
+
+	// positional arguments beyond the parameters go to the rest parameter
+	if positional > len(params) {
+		arguments = append(arguments, values[len(params):positional]...)
+	}
+	return arguments, nil
+}
+
+func (lf *LoxFunction) String() string {
+	if lf.declaration.name.Lexeme == "" {
+		return "<fn anonymous>"
+	}
+	return fmt.Sprintf("<fn %s>", lf.declaration.name.Lexeme)
 }
@@ -0,0 +1,57 @@
+package lox
+
+import (
+	"fmt"
+)
+
+type LoxClass struct {
+	name       string
+	superclass *LoxClass
+	methods    map[string]*LoxFunction
+}
+
+func NewLoxClass(name string, superclass *LoxClass, methods map[string]*LoxFunction) *LoxClass {
+	return &LoxClass{name: name, superclass: superclass, methods: methods}
+}
+
+// findMethod walks up the superclass chain until it finds the method
+func (lc *LoxClass) findMethod(name string) (*LoxFunction, bool) {
+	if method, ok := lc.methods[name]; ok {
+		return method, true
+	}
+	if lc.superclass != nil {
+		return lc.superclass.findMethod(name)
+	}
+	return nil, false
+}
+
+func (lc *LoxClass) isSubclassOf(other *LoxClass) bool {
+	for class := lc; class != nil; class = class.superclass {
+		if class == other {
+			return true
+		}
+	}
+	return false
+}
+
+func (lc *LoxClass) Arity() (int, int) {
+	if initializer, ok := lc.findMethod("init"); ok {
+		return initializer.Arity()
+	}
+	return 0, 0
+}
+
+// calling a class creates a new instance and runs its initializer (if any)
+func (lc *LoxClass) Call(i *Interpreter, arguments []any) (any, LoxError) {
+	instance := NewLoxInstance(lc)
+	if initializer, ok := lc.findMethod("init"); ok {
+		if _, err := initializer.bind(instance).Call(i, arguments); err != nil {
+			return nil, err
+		}
+	}
+	return instance, nil
+}
+
+func (lc *LoxClass) String() string {
+	return fmt.Sprintf("<class %s>", lc.name)
+}
@@ -0,0 +1,223 @@
+package lox
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// LoxGenerator is what calling a generator function returns. The body of the
+// function runs on a goroutine of its own, one step at a time: next() resumes it
+// until it yields a value and waits until it does, so there is always only one
+// goroutine running Lox code. The goroutine has a copy of the interpreter, the
+// environments it goes through are its own.
+//
+// A generator that is not run to the end leaves its goroutine waiting until it
+// is closed, by close() or by a for-in loop left early, or until the generator
+// is garbage collected. A generator its own body can reach (through a
+// variable it reads, say) is only freed when it is closed.
+type LoxGenerator struct {
+	function    *LoxFunction
+	environment *Environment
+	routine     *generatorRoutine
+	// whether the body is running, it can't be resumed from inside itself
+	running bool
+
+	// done can only be told by running the body up to the next yield, the
+	// value it yields is kept for the following next()
+	buffered bool
+	value    any
+	finished bool
+}
+
+// generatorRoutine is the goroutine running the body of a generator. It does
+// not refer to the LoxGenerator, which can be garbage collected while the
+// body waits at a yield.
+type generatorRoutine struct {
+	resume chan generatorSignal
+	steps  chan generatorStep
+	// set once the body is stopped, it can't yield anymore then
+	stopped bool
+	// set when the body is abandoned, see yield
+	abandoned bool
+}
+
+type generatorSignal int
+
+const (
+	// run the body up to the next yield
+	generatorResume generatorSignal = iota
+	// unwind the body, running its finally blocks
+	generatorClose
+	// end the goroutine without running any more Lox code, the generator is
+	// garbage collected and the code calling next() may be running meanwhile
+	generatorAbandon
+)
+
+// generatorStep is a yielded value, or the end of the body with the error
+// that ended it (if any)
+type generatorStep struct {
+	value    any
+	finished bool
+	err      LoxError
+}
+
+func NewLoxGenerator(function *LoxFunction, environment *Environment) *LoxGenerator {
+	return &LoxGenerator{function: function, environment: environment}
+}
+
+// get returns the methods next() and close() and the property done. Reading
+// done runs the body up to the next yield (to tell whether there is one), so
+// the side effects of that part of the body happen when done is read.
+func (g *LoxGenerator) get(i *Interpreter, name Token) (any, LoxError) {
+	switch name.Lexeme {
+	case "next":
+		return NewNativeFunction("next", 0, func(i *Interpreter, arguments []any) (any, LoxError) {
+			return g.next(i, name)
+		}), nil
+	case "close":
+		return NewNativeFunction("close", 0, func(i *Interpreter, arguments []any) (any, LoxError) {
+			return nil, g.close(name)
+		}), nil
+	case "done":
+		if err := g.advance(i, name); err != nil {
+			return nil, err
+		}
+		return g.finished, nil
+	}
+	return nil, &RuntimeErrorObj{name, "Undefined property '" + name.Lexeme + "'"}
+}
+
+// next returns the next value the generator yields, or nil once it is done
+func (g *LoxGenerator) next(i *Interpreter, token Token) (any, LoxError) {
+	if err := g.advance(i, token); err != nil {
+		return nil, err
+	}
+	if g.finished {
+		return nil, nil
+	}
+	g.buffered = false
+	return g.value, nil
+}
+
+// advance runs the body up to the next yield unless a value is buffered already
+func (g *LoxGenerator) advance(i *Interpreter, token Token) LoxError {
+	if g.buffered || g.finished {
+		return nil
+	}
+	if g.running {
+		return &RuntimeErrorObj{token, "Generator is already running"}
+	}
+
+	if g.routine == nil {
+		g.routine = &generatorRoutine{resume: make(chan generatorSignal), steps: make(chan generatorStep)}
+		// the environment of the caller is not the goroutine's to keep alive,
+		// it may hold the generator
+		runner := *i
+		runner.environment, runner.generator = nil, g.routine
+		go runGenerator(&runner, g.routine, g.function, g.environment)
+		runtime.SetFinalizer(g, (*LoxGenerator).abandon)
+		return g.receive()
+	}
+	return g.send(generatorResume)
+}
+
+// close stops the body waiting at a yield, running the finally blocks around
+// it, and ends its goroutine. The generator is done afterwards.
+func (g *LoxGenerator) close(token Token) LoxError {
+	if g.running {
+		return &RuntimeErrorObj{token, "Generator is already running"}
+	}
+	if g.finished {
+		return nil
+	}
+	g.buffered, g.value = false, nil
+	if g.routine == nil {
+		g.finished = true
+		return nil
+	}
+	return g.send(generatorClose)
+}
+
+// send resumes the body waiting at a yield and waits for its next step
+func (g *LoxGenerator) send(signal generatorSignal) LoxError {
+	g.running = true
+	g.routine.resume <- signal
+	return g.receive()
+}
+
+func (g *LoxGenerator) receive() LoxError {
+	g.running = true
+	step := <-g.routine.steps
+	g.running = false
+
+	if step.finished {
+		g.finished = true
+		return step.err
+	}
+	g.buffered, g.value = true, step.value
+	return nil
+}
+
+// abandon is the finalizer of a generator, it ends the goroutine of a body
+// waiting at a yield
+func (g *LoxGenerator) abandon() {
+	if g.routine != nil && !g.finished {
+		g.routine.resume <- generatorAbandon
+	}
+}
+
+func runGenerator(i *Interpreter, routine *generatorRoutine, function *LoxFunction, environment *Environment) {
+	i.globals = function.globals
+	_, err := i.executeBlock(function.declaration.body, environment)
+	switch err.(type) {
+	case *ReturnObj, *generatorStopObj:
+		err = nil
+	}
+	routine.steps <- generatorStep{finished: true, err: err}
+}
+
+// generatorStopObj unwinds the body of a generator that is closed
+type generatorStopObj struct {
+	RuntimeErrorObj
+}
+
+// yield hands the value over to the code that called next() and waits until
+// the generator is resumed, closed or abandoned
+func (r *generatorRoutine) yield(keyword Token, value any) LoxError {
+	stop := &generatorStopObj{RuntimeErrorObj{keyword, "stop"}}
+	if r.stopped {
+		// a finally block yielding while the generator is closed
+		return stop
+	}
+	r.steps <- generatorStep{value: value}
+	switch <-r.resume {
+	case generatorClose:
+		r.stopped = true
+		return stop
+	case generatorAbandon:
+		// only deferred Go code runs from here on, on the copy of the
+		// interpreter of this goroutine
+		r.abandoned = true
+		runtime.Goexit()
+	}
+	return nil
+}
+
+func (g *LoxGenerator) String() string {
+	if g.function.declaration.name.Lexeme == "" {
+		return "<generator anonymous>"
+	}
+	return fmt.Sprintf("<generator %s>", g.function.declaration.name.Lexeme)
+}
+
+func (i *Interpreter) VisitYieldStmt(stmt *YieldStmt) (any, LoxError) {
+	var value any
+	if stmt.value != nil {
+		var err LoxError
+		value, err = i.evaluate(stmt.value)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return nil, i.generator.yield(stmt.keyword, value)
+}
@@ -0,0 +1,69 @@
+package lox
+
+import (
+	"runtime"
+	"testing"
+	"time"
+)
+
+const naturals = `
+fun naturals() {
+  var n = 0;
+  while (true) {
+    n = n + 1;
+    yield n;
+  }
+}
+`
+
+// runGenerators runs the source and tells whether the generator goroutines it
+// started have ended, collecting the garbage in case they wait for that
+func runGenerators(t *testing.T, source string) {
+	before := runtime.NumGoroutine()
+
+	scanner := NewScanner(naturals + source)
+	statements := NewParser(scanner.ScanTokens()).Parse()
+	interpreter := NewInterpreter()
+	NewResolver(interpreter).Resolve(statements)
+	if HadError {
+		t.Fatalf("failed: source does not compile")
+	}
+	interpreter.Interpret(statements)
+
+	for attempt := 0; runtime.NumGoroutine() > before && attempt < 200; attempt++ {
+		runtime.GC()
+		time.Sleep(time.Millisecond)
+	}
+	if after := runtime.NumGoroutine(); after > before {
+		t.Errorf("failed: %d generator goroutines left running", after-before)
+	}
+}
+
+func TestBreakClosesGenerator(t *testing.T) {
+	runGenerators(t, `
+for (var j = 0; j < 100; j++) {
+  for (var n in naturals()) {
+    if (n > 2) break;
+  }
+}
+`)
+}
+
+func TestCloseGenerator(t *testing.T) {
+	runGenerators(t, `
+for (var j = 0; j < 100; j++) {
+  var g = naturals();
+  g.next();
+  g.close();
+}
+`)
+}
+
+func TestAbandonedGeneratorsEnd(t *testing.T) {
+	runGenerators(t, `
+fun first(g) { return g.next(); }
+for (var j = 0; j < 100; j++) {
+  first(naturals());
+}
+`)
+}
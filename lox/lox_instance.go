@@ -0,0 +1,33 @@
+package lox
+
+import (
+	"fmt"
+)
+
+type LoxInstance struct {
+	class  *LoxClass
+	fields map[string]any
+}
+
+func NewLoxInstance(class *LoxClass) *LoxInstance {
+	return &LoxInstance{class: class, fields: make(map[string]any)}
+}
+
+// fields shadow methods of the same name
+func (li *LoxInstance) get(name Token) (any, RuntimeError) {
+	if value, ok := li.fields[name.Lexeme]; ok {
+		return value, nil
+	}
+	if method, ok := li.class.findMethod(name.Lexeme); ok {
+		return method.bind(li), nil
+	}
+	return nil, &RuntimeErrorObj{name, "Undefined property '" + name.Lexeme + "'"}
+}
+
+func (li *LoxInstance) set(name Token, value any) {
+	li.fields[name.Lexeme] = value
+}
+
+func (li *LoxInstance) String() string {
+	return fmt.Sprintf("<%s instance>", li.class.name)
+}
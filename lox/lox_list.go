@@ -0,0 +1,132 @@
+package lox
+
+import (
+	"math"
+	"strings"
+	"unicode/utf8"
+)
+
+type LoxList struct {
+	elements []any
+}
+
+func NewLoxList(elements []any) *LoxList {
+	return &LoxList{elements: elements}
+}
+
+func (l *LoxList) get(bracket Token, index any) (any, RuntimeError) {
+	position, err := l.position(bracket, index)
+	if err != nil {
+		return nil, err
+	}
+	return l.elements[position], nil
+}
+
+func (l *LoxList) set(bracket Token, index any, value any) (any, RuntimeError) {
+	position, err := l.position(bracket, index)
+	if err != nil {
+		return nil, err
+	}
+	l.elements[position] = value
+	return value, nil
+}
+
+// position validates that index is a whole number pointing into the list
+func (l *LoxList) position(bracket Token, index any) (int, RuntimeError) {
+	number, ok := index.(float64)
+	if !ok || number != math.Trunc(number) {
+		return 0, &RuntimeErrorObj{bracket, "List index must be an integer"}
+	}
+	if number < 0 || number >= float64(len(l.elements)) {
+		return 0, &RuntimeErrorObj{bracket, "List index out of range"}
+	}
+	return int(number), nil
+}
+
+func (l *LoxList) String() string {
+	var sb strings.Builder
+	sb.WriteString("[")
+	for j, element := range l.elements {
+		if j > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(repr(element))
+	}
+	sb.WriteString("]")
+	return sb.String()
+}
+
+// repr is like stringify, but quotes strings so that they can be told apart
+// inside of collections
+func repr(object any) string {
+	if s, ok := object.(string); ok {
+		return "\"" + s + "\""
+	}
+	return stringify(object)
+}
+
+// ------------------------------------------------------------------------------------------
+var listNatives = []*NativeFunction{
+	NewNativeFunction("len", 1, nativeLen),
+	NewNativeFunction("append", 2, nativeAppend),
+	NewNativeFunction("pop", 1, nativePop),
+	NewNativeFunctionRange("slice", 2, 3, nativeSlice),
+}
+
+func nativeLen(i *Interpreter, arguments []any) (any, LoxError) {
+	switch value := arguments[0].(type) {
+	case *LoxList:
+		return float64(len(value.elements)), nil
+	case *LoxMap:
+		return float64(len(value.keys)), nil
+	case string:
+		return float64(utf8.RuneCountInString(value)), nil
+	}
+	return nil, nativeError("Can't take the length of %s", stringify(arguments[0]))
+}
+
+func nativeAppend(i *Interpreter, arguments []any) (any, LoxError) {
+	list, ok := arguments[0].(*LoxList)
+	if !ok {
+		return nil, nativeError("Can only append to a list")
+	}
+	list.elements = append(list.elements, arguments[1])
+	return nil, nil
+}
+
+func nativePop(i *Interpreter, arguments []any) (any, LoxError) {
+	list, ok := arguments[0].(*LoxList)
+	if !ok {
+		return nil, nativeError("Can only pop from a list")
+	}
+	if len(list.elements) == 0 {
+		return nil, nativeError("Can't pop from an empty list")
+	}
+	last := list.elements[len(list.elements)-1]
+	list.elements = list.elements[:len(list.elements)-1]
+	return last, nil
+}
+
+// slice(list, start, end) returns a new list with the elements from start up
+// to, but not including, end
+func nativeSlice(i *Interpreter, arguments []any) (any, LoxError) {
+	list, ok := arguments[0].(*LoxList)
+	if !ok {
+		return nil, nativeError("Can only slice a list")
+	}
+	start, startOk := arguments[1].(float64)
+	// without an end the slice goes to the end of the list
+	end, endOk := float64(len(list.elements)), true
+	if len(arguments) > 2 {
+		end, endOk = arguments[2].(float64)
+	}
+	if !startOk || !endOk || start != math.Trunc(start) || end != math.Trunc(end) {
+		return nil, nativeError("Slice bounds must be integers")
+	}
+	if start < 0 || end > float64(len(list.elements)) || start > end {
+		return nil, nativeError("Slice bounds out of range")
+	}
+	elements := make([]any, int(end-start))
+	copy(elements, list.elements[int(start):int(end)])
+	return NewLoxList(elements), nil
+}
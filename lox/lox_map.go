@@ -0,0 +1,143 @@
+package lox
+
+import (
+	"strings"
+)
+
+// LoxMap is a dictionary that remembers the order in which keys were inserted.
+type LoxMap struct {
+	keys    []any
+	entries map[any]any
+}
+
+func NewLoxMap() *LoxMap {
+	return &LoxMap{keys: []any{}, entries: make(map[any]any)}
+}
+
+// only values that compare by value can be used as keys
+func isHashable(key any) bool {
+	switch key.(type) {
+	case nil, float64, string, bool:
+		return true
+	}
+	return false
+}
+
+func (m *LoxMap) get(bracket Token, key any) (any, RuntimeError) {
+	if !isHashable(key) {
+		return nil, &RuntimeErrorObj{bracket, "Map keys must be numbers, strings, booleans or nil"}
+	}
+	value, ok := m.entries[key]
+	if !ok {
+		return nil, &RuntimeErrorObj{bracket, "Key not found: " + repr(key)}
+	}
+	return value, nil
+}
+
+func (m *LoxMap) set(bracket Token, key any, value any) (any, RuntimeError) {
+	if !isHashable(key) {
+		return nil, &RuntimeErrorObj{bracket, "Map keys must be numbers, strings, booleans or nil"}
+	}
+	m.put(key, value)
+	return value, nil
+}
+
+func (m *LoxMap) put(key any, value any) {
+	if _, ok := m.entries[key]; !ok {
+		m.keys = append(m.keys, key)
+	}
+	m.entries[key] = value
+}
+
+func (m *LoxMap) remove(key any) bool {
+	if _, ok := m.entries[key]; !ok {
+		return false
+	}
+	delete(m.entries, key)
+	for j, k := range m.keys {
+		if k == key {
+			m.keys = append(m.keys[:j], m.keys[j+1:]...)
+			break
+		}
+	}
+	return true
+}
+
+// Keys returns the keys in insertion order, so that the host program can
+// read the data a script produced.
+func (m *LoxMap) Keys() []any {
+	return append([]any{}, m.keys...)
+}
+
+// Get returns the value stored under key.
+func (m *LoxMap) Get(key any) (any, bool) {
+	value, ok := m.entries[key]
+	return value, ok
+}
+
+func (m *LoxMap) String() string {
+	var sb strings.Builder
+	sb.WriteString("{")
+	for j, key := range m.keys {
+		if j > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(repr(key))
+		sb.WriteString(": ")
+		sb.WriteString(repr(m.entries[key]))
+	}
+	sb.WriteString("}")
+	return sb.String()
+}
+
+// ------------------------------------------------------------------------------------------
+var mapNatives = []*NativeFunction{
+	NewNativeFunction("keys", 1, nativeKeys),
+	NewNativeFunction("values", 1, nativeValues),
+	NewNativeFunction("has", 2, nativeHas),
+	NewNativeFunction("delete", 2, nativeDelete),
+}
+
+func nativeKeys(i *Interpreter, arguments []any) (any, LoxError) {
+	m, ok := arguments[0].(*LoxMap)
+	if !ok {
+		return nil, nativeError("Can only get the keys of a map")
+	}
+	return NewLoxList(m.Keys()), nil
+}
+
+func nativeValues(i *Interpreter, arguments []any) (any, LoxError) {
+	m, ok := arguments[0].(*LoxMap)
+	if !ok {
+		return nil, nativeError("Can only get the values of a map")
+	}
+	values := make([]any, 0, len(m.keys))
+	for _, key := range m.keys {
+		values = append(values, m.entries[key])
+	}
+	return NewLoxList(values), nil
+}
+
+func nativeHas(i *Interpreter, arguments []any) (any, LoxError) {
+	m, ok := arguments[0].(*LoxMap)
+	if !ok {
+		return nil, nativeError("Can only look up keys in a map")
+	}
+	if !isHashable(arguments[1]) {
+		return nil, nativeError("Map keys must be numbers, strings, booleans or nil")
+	}
+	_, found := m.entries[arguments[1]]
+	return found, nil
+}
+
+// delete(map, key) removes the key and tells whether it was there
+func nativeDelete(i *Interpreter, arguments []any) (any, LoxError) {
+	m, ok := arguments[0].(*LoxMap)
+	if !ok {
+		return nil, nativeError("Can only delete keys from a map")
+	}
+	if !isHashable(arguments[1]) {
+		return nil, nativeError("Map keys must be numbers, strings, booleans or nil")
+	}
+	return m.remove(arguments[1]), nil
+}
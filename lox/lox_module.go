@@ -0,0 +1,183 @@
+package lox
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LoxModule is the value an import statement binds its name to. The top-level
+// names of the module file are its members, except the ones starting with '_'
+// which are private to the module.
+type LoxModule struct {
+	name    string
+	path    string
+	globals *Environment
+}
+
+func NewLoxModule(path string, globals *Environment) *LoxModule {
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	return &LoxModule{name: name, path: path, globals: globals}
+}
+
+func (m *LoxModule) get(name Token) (any, RuntimeError) {
+	if strings.HasPrefix(name.Lexeme, "_") {
+		return nil, &RuntimeErrorObj{name, fmt.Sprintf("'%s' is private to module %s", name.Lexeme, m.name)}
+	}
+	if _, ok := m.globals.Values[name.Lexeme]; !ok {
+		return nil, &RuntimeErrorObj{name, fmt.Sprintf("Module %s has no member '%s'", m.name, name.Lexeme)}
+	}
+	return m.globals.get(name)
+}
+
+func (m *LoxModule) String() string {
+	return fmt.Sprintf("<module %s>", m.name)
+}
+
+// SetScript tells the interpreter which file it runs, runtime errors in other
+// files (modules) name the file they are in
+func (i *Interpreter) SetScript(path string) {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	i.script = path
+	// the script itself can be part of an import cycle too
+	i.loading = []string{path}
+}
+
+// SetSearchPath sets the directories searched for modules that are not found
+// next to the importing file
+func (i *Interpreter) SetSearchPath(directories []string) {
+	i.searchPath = directories
+}
+
+// SetOptionalSemicolons tells whether the imported modules are scanned with
+// optional semicolons, see Scanner.SetOptionalSemicolons
+func (i *Interpreter) SetOptionalSemicolons(optional bool) {
+	i.optionalSemicolons = optional
+}
+
+func (i *Interpreter) VisitImportStmt(stmt *ImportStmt) (any, LoxError) {
+	module, err := i.importModule(stmt.path)
+	if err != nil {
+		return nil, err
+	}
+	if err := i.environment.declare(stmt.name, module); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+// importModule returns the module the path token refers to, loading and
+// running it the first time it is imported
+func (i *Interpreter) importModule(pathToken Token) (*LoxModule, LoxError) {
+	path, err := i.findModule(pathToken)
+	if err != nil {
+		return nil, err
+	}
+	if module, ok := i.modules[path]; ok {
+		return module, nil
+	}
+	for j, loading := range i.loading {
+		if loading == path {
+			var cycle []string
+			for _, p := range i.loading[j:] {
+				cycle = append(cycle, displayPath(p))
+			}
+			cycle = append(cycle, displayPath(path))
+			return nil, &RuntimeErrorObj{pathToken, "Import cycle: " + strings.Join(cycle, " -> ")}
+		}
+	}
+
+	source, readErr := os.ReadFile(path)
+	if readErr != nil {
+		return nil, &RuntimeErrorObj{pathToken, fmt.Sprintf("Could not read module '%s'", displayPath(path))}
+	}
+
+	// errors of the module are reported as they are found, HadError only
+	// tells whether there were any in this one
+	hadError := HadError
+	HadError = false
+	scanner := NewScanner(string(source))
+	scanner.SetFile(path)
+	scanner.SetOptionalSemicolons(i.optionalSemicolons)
+	statements := NewParser(scanner.ScanTokens()).Parse()
+	if !HadError {
+		NewResolver(i).Resolve(statements)
+	}
+	failed := HadError
+	HadError = HadError || hadError
+	if failed {
+		return nil, &RuntimeErrorObj{pathToken, fmt.Sprintf("Could not compile module '%s'", displayPath(path))}
+	}
+
+	module := NewLoxModule(path, NewEnvironment(i.builtins))
+	if err := i.runModule(module, statements); err != nil {
+		return nil, err
+	}
+	i.modules[path] = module
+	return module, nil
+}
+
+func (i *Interpreter) runModule(module *LoxModule, statements []Stmt) LoxError {
+	previousGlobals, previousEnvironment := i.globals, i.environment
+	i.globals, i.environment = module.globals, module.globals
+	i.loading = append(i.loading, module.path)
+	defer func() {
+		i.globals, i.environment = previousGlobals, previousEnvironment
+		i.loading = i.loading[:len(i.loading)-1]
+	}()
+
+	for _, statement := range statements {
+		if _, err := i.execute(statement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// findModule returns the absolute path of the first existing file among the
+// path relative to the importing file and the path relative to the search path
+// directories
+func (i *Interpreter) findModule(pathToken Token) (string, RuntimeError) {
+	name := pathToken.Literal.(string)
+
+	var candidates []string
+	if filepath.IsAbs(name) {
+		candidates = []string{name}
+	} else {
+		directory := "."
+		if pathToken.File != "" {
+			directory = filepath.Dir(pathToken.File)
+		}
+		candidates = append(candidates, filepath.Join(directory, name))
+		for _, directory := range i.searchPath {
+			candidates = append(candidates, filepath.Join(directory, name))
+		}
+	}
+
+	for _, candidate := range candidates {
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			if abs, err := filepath.Abs(candidate); err == nil {
+				return abs, nil
+			}
+			return candidate, nil
+		}
+	}
+	return "", &RuntimeErrorObj{pathToken, fmt.Sprintf("Module '%s' not found", name)}
+}
+
+// displayPath shortens the path for error messages when it is below the
+// working directory
+func displayPath(path string) string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return path
+	}
+	relative, err := filepath.Rel(wd, path)
+	if err != nil || strings.HasPrefix(relative, "..") {
+		return path
+	}
+	return relative
+}
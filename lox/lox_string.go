@@ -0,0 +1,170 @@
+package lox
+
+import (
+	"math"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// stringMethod is a method of the string values, it gets the string it is
+// called on and the arguments of the call. Positions and lengths are counted
+// in characters (runes), not bytes.
+type stringMethod struct {
+	minArity int
+	maxArity int
+	function func(s string, arguments []any) (any, LoxError)
+}
+
+var stringMethods = map[string]stringMethod{
+	"upper":      {0, 0, stringUpper},
+	"lower":      {0, 0, stringLower},
+	"trim":       {0, 0, stringTrim},
+	"trimStart":  {0, 0, stringTrimStart},
+	"trimEnd":    {0, 0, stringTrimEnd},
+	"split":      {1, 1, stringSplit},
+	"slice":      {1, 2, stringSlice},
+	"indexOf":    {1, 1, stringIndexOf},
+	"contains":   {1, 1, stringContains},
+	"startsWith": {1, 1, stringStartsWith},
+	"endsWith":   {1, 1, stringEndsWith},
+	"replace":    {2, 2, stringReplace},
+	"join":       {1, 1, stringJoin},
+}
+
+// stringProperty returns the length of the string or one of its methods
+func stringProperty(s string, name Token) (any, LoxError) {
+	if name.Lexeme == "length" {
+		return float64(utf8.RuneCountInString(s)), nil
+	}
+	method, ok := stringMethods[name.Lexeme]
+	if !ok {
+		return nil, &RuntimeErrorObj{name, "Undefined property '" + name.Lexeme + "'"}
+	}
+	return NewNativeFunctionRange(name.Lexeme, method.minArity, method.maxArity, func(i *Interpreter, arguments []any) (any, LoxError) {
+		return method.function(s, arguments)
+	}), nil
+}
+
+func stringArgument(argument any, method string) (string, LoxError) {
+	s, ok := argument.(string)
+	if !ok {
+		return "", nativeError("Argument of %s must be a string but got %s", method, repr(argument))
+	}
+	return s, nil
+}
+
+func stringUpper(s string, arguments []any) (any, LoxError) {
+	return strings.ToUpper(s), nil
+}
+
+func stringLower(s string, arguments []any) (any, LoxError) {
+	return strings.ToLower(s), nil
+}
+
+func stringTrim(s string, arguments []any) (any, LoxError) {
+	return strings.TrimSpace(s), nil
+}
+
+func stringTrimStart(s string, arguments []any) (any, LoxError) {
+	return strings.TrimLeftFunc(s, unicode.IsSpace), nil
+}
+
+func stringTrimEnd(s string, arguments []any) (any, LoxError) {
+	return strings.TrimRightFunc(s, unicode.IsSpace), nil
+}
+
+// split("") splits the string into its characters
+func stringSplit(s string, arguments []any) (any, LoxError) {
+	separator, err := stringArgument(arguments[0], "split")
+	if err != nil {
+		return nil, err
+	}
+	parts := strings.Split(s, separator)
+	elements := make([]any, len(parts))
+	for j, part := range parts {
+		elements[j] = part
+	}
+	return NewLoxList(elements), nil
+}
+
+// slice(start) or slice(start, end), the end is not included
+func stringSlice(s string, arguments []any) (any, LoxError) {
+	runes := []rune(s)
+	bounds := []float64{0, float64(len(runes))}
+	for j, argument := range arguments {
+		bound, ok := argument.(float64)
+		if !ok || bound != math.Trunc(bound) {
+			return nil, nativeError("Slice bounds must be integers")
+		}
+		bounds[j] = bound
+	}
+	start, end := bounds[0], bounds[1]
+	if start < 0 || end > float64(len(runes)) || start > end {
+		return nil, nativeError("Slice bounds out of range")
+	}
+	return string(runes[int(start):int(end)]), nil
+}
+
+// indexOf returns the position of the first occurrence of the substring, or -1
+func stringIndexOf(s string, arguments []any) (any, LoxError) {
+	substring, err := stringArgument(arguments[0], "indexOf")
+	if err != nil {
+		return nil, err
+	}
+	index := strings.Index(s, substring)
+	if index < 0 {
+		return float64(-1), nil
+	}
+	return float64(utf8.RuneCountInString(s[:index])), nil
+}
+
+func stringContains(s string, arguments []any) (any, LoxError) {
+	substring, err := stringArgument(arguments[0], "contains")
+	if err != nil {
+		return nil, err
+	}
+	return strings.Contains(s, substring), nil
+}
+
+func stringStartsWith(s string, arguments []any) (any, LoxError) {
+	prefix, err := stringArgument(arguments[0], "startsWith")
+	if err != nil {
+		return nil, err
+	}
+	return strings.HasPrefix(s, prefix), nil
+}
+
+func stringEndsWith(s string, arguments []any) (any, LoxError) {
+	suffix, err := stringArgument(arguments[0], "endsWith")
+	if err != nil {
+		return nil, err
+	}
+	return strings.HasSuffix(s, suffix), nil
+}
+
+// replace replaces all occurrences
+func stringReplace(s string, arguments []any) (any, LoxError) {
+	old, err := stringArgument(arguments[0], "replace")
+	if err != nil {
+		return nil, err
+	}
+	replacement, err := stringArgument(arguments[1], "replace")
+	if err != nil {
+		return nil, err
+	}
+	return strings.ReplaceAll(s, old, replacement), nil
+}
+
+// ", ".join(list) joins the elements of the list with the string between them
+func stringJoin(s string, arguments []any) (any, LoxError) {
+	list, ok := arguments[0].(*LoxList)
+	if !ok {
+		return nil, nativeError("Argument of join must be a list but got %s", repr(arguments[0]))
+	}
+	parts := make([]string, len(list.elements))
+	for j, element := range list.elements {
+		parts[j] = stringify(element)
+	}
+	return strings.Join(parts, s), nil
+}
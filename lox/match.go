@@ -0,0 +1,61 @@
+package lox
+
+// MatchArm is one 'case pattern if guard => body' of a match statement, the
+// guard is nil when there is none
+type MatchArm struct {
+	pattern Pattern
+	guard   Expr
+	body    Stmt
+}
+
+func NewMatchArm(pattern Pattern, guard Expr, body Stmt) *MatchArm {
+	return &MatchArm{pattern: pattern, guard: guard, body: body}
+}
+
+// patternMatcher tests a value against a pattern and collects the variables
+// the pattern binds. Patterns of compound values will match their parts by
+// calling match recursively with the part as the value.
+type patternMatcher struct {
+	interpreter *Interpreter
+	value       any
+	bindings    map[string]any
+}
+
+func newPatternMatcher(interpreter *Interpreter) *patternMatcher {
+	return &patternMatcher{interpreter: interpreter, bindings: make(map[string]any)}
+}
+
+func (m *patternMatcher) match(pattern Pattern, value any) (bool, LoxError) {
+	previous := m.value
+	m.value = value
+	defer func() { m.value = previous }()
+
+	matched, err := pattern.Accept(m)
+	if err != nil {
+		return false, err
+	}
+	return matched.(bool), nil
+}
+
+func (m *patternMatcher) VisitAlternativePattern(pattern *AlternativePattern) (any, LoxError) {
+	for _, alternative := range pattern.alternatives {
+		matched, err := m.match(alternative, m.value)
+		if err != nil || matched {
+			return matched, err
+		}
+	}
+	return false, nil
+}
+
+func (m *patternMatcher) VisitBindingPattern(pattern *BindingPattern) (any, LoxError) {
+	m.bindings[pattern.name.Lexeme] = m.value
+	return true, nil
+}
+
+func (m *patternMatcher) VisitLiteralPattern(pattern *LiteralPattern) (any, LoxError) {
+	return m.interpreter.isEqual(m.value, pattern.value)
+}
+
+func (m *patternMatcher) VisitWildcardPattern(pattern *WildcardPattern) (any, LoxError) {
+	return true, nil
+}
@@ -0,0 +1,67 @@
+package lox
+
+import (
+	"fmt"
+)
+
+// NativeFunction is a LoxCallable implemented in go.
+type NativeFunction struct {
+	name     string
+	minArity int
+	maxArity int
+	function func(i *Interpreter, arguments []any) (any, LoxError)
+}
+
+func NewNativeFunction(name string, arity int, function func(*Interpreter, []any) (any, LoxError)) *NativeFunction {
+	return NewNativeFunctionRange(name, arity, arity, function)
+}
+
+// NewNativeFunctionRange creates a native function taking from minArity to
+// maxArity arguments (or any number from minArity on when maxArity is
+// unlimitedArity), the function gets only the arguments given in the call
+func NewNativeFunctionRange(name string, minArity, maxArity int, function func(*Interpreter, []any) (any, LoxError)) *NativeFunction {
+	return &NativeFunction{name: name, minArity: minArity, maxArity: maxArity, function: function}
+}
+
+func (nf *NativeFunction) Arity() (int, int) {
+	return nf.minArity, nf.maxArity
+}
+
+func (nf *NativeFunction) Call(i *Interpreter, arguments []any) (any, LoxError) {
+	return nf.function(i, arguments)
+}
+
+func (nf *NativeFunction) String() string {
+	return fmt.Sprintf("<native fn %s>", nf.name)
+}
+
+// NativeErrorObj is returned by native functions, which do not know where they
+// were called from. The interpreter turns it into a RuntimeError reported at
+// the call site.
+type NativeErrorObj struct {
+	message string
+}
+
+func (e *NativeErrorObj) GetToken() Token {
+	return Token{}
+}
+
+func (e *NativeErrorObj) GetMessage() string {
+	return e.message
+}
+
+func nativeError(format string, args ...any) *NativeErrorObj {
+	return &NativeErrorObj{fmt.Sprintf(format, args...)}
+}
+
+// callFunction calls a Lox callable passed to a native function as an argument
+func callFunction(i *Interpreter, callee any, arguments ...any) (any, LoxError) {
+	function, ok := callee.(LoxCallable)
+	if !ok {
+		return nil, nativeError("Expected a function but got %s", stringify(callee))
+	}
+	if !acceptsArguments(function, len(arguments)) {
+		return nil, nativeError("Expected a function taking %d arguments but it takes %s", len(arguments), describeArity(function))
+	}
+	return function.Call(i, arguments)
+}
@@ -1,14 +1,43 @@
 package lox
 
+import "strings"
+
 // recursive descent parser for (g)lox interpreter
 
 type Parser struct {
 	tokens  []Token
 	current int
+	// number of loops enclosing the statement being parsed; 'break' and
+	// 'continue' are only allowed when it is not zero
+	loopDepth int
+	// whether the function body being parsed contains a 'yield', which makes
+	// the function a generator
+	yields bool
+	// the doc comments, by the offset of the token they are written before
+	docs map[int]string
 }
 
+// NewParser takes the doc comments out of the tokens. They are only attached
+// when the token right after them starts a declaration (the keyword, or the
+// name of a method), elsewhere they are ignored.
 func NewParser(tokens []Token) *Parser {
-	return &Parser{tokens: tokens, current: 0}
+	parser := &Parser{tokens: make([]Token, 0, len(tokens)), current: 0, docs: make(map[int]string)}
+	var doc []string
+	for _, token := range tokens {
+		if token.TokenType == DOC_COMMENT {
+			doc = append(doc, token.Literal.(string))
+			continue
+		}
+		switch token.TokenType {
+		case FUN, CLASS, VAR, CONST, IDENTIFIER:
+			if doc != nil {
+				parser.docs[token.Offset] = strings.Join(doc, "\n")
+			}
+		}
+		doc = nil
+		parser.tokens = append(parser.tokens, token)
+	}
+	return parser
 }
 
 func (p *Parser) Parse() []Stmt {
@@ -18,7 +47,7 @@ func (p *Parser) Parse() []Stmt {
 	// }
 
 	statements := []Stmt{}
-	for !p.isAtEnd() {
+	for p.skipLineEnds(); !p.isAtEnd(); p.skipLineEnds() {
 		stmt, err := p.declaration()
 		if err != nil {
 			parserError(err)
@@ -31,22 +60,44 @@ func (p *Parser) Parse() []Stmt {
 
 // The lox grammar:
 // ----------------
-// expression     → equality ;
+// expression     → assignment ;
+// assignment     → target ( "=" | "+=" | "-=" | "*=" | "/=" ) assignment
+//                | conditional ;
+// target         → ( call "." )? IDENTIFIER | call "[" expression "]" ;
+// conditional    → coalesce ( "?" expression ":" conditional )? ;
+// coalesce       → logic_or ( "??" logic_or )* ;
+// logic_or       → logic_and ( "or" logic_and )* ;
+// logic_and      → equality ( "and" equality )* ;
 // equality       → comparison ( ( "!=" | "==" ) comparison )* ;
 // comparison     → term ( ( ">" | ">=" | "<" | "<=" ) term )* ;
 // term           → factor ( ( "-" | "+" ) factor )* ;
-// factor         → unary ( ( "/" | "*" ) unary )* ;
+// factor         → unary ( ( "/" | "*" | "~/" | "%" ) unary )* ;
 // unary          → ( "!" | "-" ) unary
-//                | primary ;
-// primary        → NUMBER | STRING | "true" | "false" | "nil"
-//                | "(" expression ")" ;
+//                | ( "++" | "--" ) target
+//                | power ;
+// power          → postfix ( "**" unary )? ;
+// postfix        → call ( "++" | "--" )? ;
+// call           → primary ( "(" arguments? ")" | "." IDENTIFIER | "[" expression "]" )* ;
+// primary        → NUMBER | STRING | "true" | "false" | "nil" | "this"
+//                | ( INTERPOLATION expression )+ STRING
+//                | IDENTIFIER | "(" expression ")"
+//                | "fun" "(" parameters? ")" block
+//                | "(" parameters? ")" "=>" ( expression | block )
+//                | "[" ( expression ( "," expression )* )? "]"
+//                | "{" ( expression ":" expression ( "," expression ":" expression )* )? "}"
+//                | "super" "." IDENTIFIER ;
+// arguments      → argument ( "," argument )* ;
+// argument       → ( IDENTIFIER ":" )? expression ;
+// parameters     → parameter ( "," parameter )* ( "," "..." IDENTIFIER )?
+//                | "..." IDENTIFIER ;
+// parameter      → IDENTIFIER ( "=" expression )? ;
 
 func (p *Parser) expression() (Expr, ParserError) {
 	return p.assignment()
 }
 
 func (p *Parser) assignment() (Expr, ParserError) {
-	expr, err := p.or()
+	expr, err := p.conditional()
 	if err != nil {
 		return nil, err
 	}
@@ -58,12 +109,77 @@ func (p *Parser) assignment() (Expr, ParserError) {
 			return nil, err
 		}
 
-		variable_expr, ok := expr.(VariableExpr)
-		if ok {
-			name := variable_expr.name
-			return NewAssignmentExpr(name, value), nil
+		switch target := expr.(type) {
+		case *VariableExpr:
+			nameAnonymousFunction(value, target.name)
+			return NewAssignmentExpr(target.name, value), nil
+		case *GetExpr:
+			nameAnonymousFunction(value, target.name)
+			return NewSetExpr(target.object, target.name, value), nil
+		case *IndexExpr:
+			return NewIndexSetExpr(target.object, target.bracket, target.index, value), nil
 		}
 		Error(equals, "Invalid assignment target")
+	} else if p.match(PLUS_EQUAL, MINUS_EQUAL, STAR_EQUAL, SLASH_EQUAL) {
+		operator := p.previous()
+		value, err := p.assignment()
+		if err != nil {
+			return nil, err
+		}
+		if isAssignable(expr) {
+			return NewCompoundAssignmentExpr(expr, operator, value), nil
+		}
+		Error(operator, "Invalid assignment target")
+	}
+	return expr, nil
+}
+
+// isAssignable tells whether expr can be the target of an assignment
+func isAssignable(expr Expr) bool {
+	switch expr.(type) {
+	case *VariableExpr, *GetExpr, *IndexExpr:
+		return true
+	}
+	return false
+}
+
+func (p *Parser) conditional() (Expr, ParserError) {
+	expr, err := p.coalesce()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.match(QUESTION) {
+		thenBranch, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.consume(COLON, "Expect ':' after then branch of conditional expression"); err != nil {
+			return nil, err
+		}
+		// right associative: a ? b : c ? d : e is a ? b : (c ? d : e)
+		elseBranch, err := p.conditional()
+		if err != nil {
+			return nil, err
+		}
+		expr = NewConditionalExpr(expr, thenBranch, elseBranch)
+	}
+	return expr, nil
+}
+
+func (p *Parser) coalesce() (Expr, ParserError) {
+	expr, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.match(QUESTION_QUESTION) {
+		operator := p.previous()
+		right, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		expr = NewCoalesceExpr(expr, operator, right)
 	}
 	return expr, nil
 }
@@ -163,7 +279,7 @@ func (p *Parser) factor() (Expr, ParserError) {
 		return nil, err
 	}
 
-	for p.match(SLASH, STAR) {
+	for p.match(SLASH, STAR, TILDE_SLASH, PERCENT) {
 		operator := p.previous()
 		right, err := p.unary()
 		if err != nil {
@@ -183,7 +299,55 @@ func (p *Parser) unary() (Expr, ParserError) {
 		}
 		return NewUnaryExpr(operator, right), nil
 	}
-	return p.call()
+	if p.match(PLUS_PLUS, MINUS_MINUS) {
+		operator := p.previous()
+		target, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		if !isAssignable(target) {
+			Error(operator, "Invalid increment target")
+			return target, nil
+		}
+		return NewIncrementExpr(target, operator, true), nil
+	}
+	return p.power()
+}
+
+// power binds tighter than a unary minus on its left (-2 ** 2 is -4) and is
+// right associative (2 ** 3 ** 2 is 2 ** 9), the exponent can have a sign.
+func (p *Parser) power() (Expr, ParserError) {
+	expr, err := p.postfix()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.match(STAR_STAR) {
+		operator := p.previous()
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		expr = NewBinaryExpr(expr, operator, right)
+	}
+	return expr, nil
+}
+
+func (p *Parser) postfix() (Expr, ParserError) {
+	expr, err := p.call()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.match(PLUS_PLUS, MINUS_MINUS) {
+		operator := p.previous()
+		if !isAssignable(expr) {
+			Error(operator, "Invalid increment target")
+			return expr, nil
+		}
+		return NewIncrementExpr(expr, operator, false), nil
+	}
+	return expr, nil
 }
 
 func (p *Parser) call() (Expr, ParserError) {
@@ -198,6 +362,22 @@ func (p *Parser) call() (Expr, ParserError) {
 			if err != nil {
 				return nil, err
 			}
+		} else if p.match(DOT) {
+			name, err := p.consume(IDENTIFIER, "Expect property name after '.'")
+			if err != nil {
+				return nil, err
+			}
+			expr = NewGetExpr(expr, name)
+		} else if p.match(LEFT_BRACKET) {
+			index, err := p.expression()
+			if err != nil {
+				return nil, err
+			}
+			bracket, err := p.consume(RIGHT_BRACKET, "Expect ']' after index")
+			if err != nil {
+				return nil, err
+			}
+			expr = NewIndexExpr(expr, bracket, index)
 		} else {
 			break
 		}
@@ -205,8 +385,11 @@ func (p *Parser) call() (Expr, ParserError) {
 	return expr, nil
 }
 
+// arguments are positional or named (name: value), the named ones come last
 func (p *Parser) finishCall(callee Expr) (Expr, ParserError) {
 	var arguments []Expr
+	var names []Token
+	named := false
 	if !p.check(RIGHT_PAREN) {
 		for {
 			if len(arguments) >= 255 {
@@ -217,11 +400,20 @@ func (p *Parser) finishCall(callee Expr) (Expr, ParserError) {
 				// we want to keep on parsing.
 				Error(p.peek(), "Can't have more than 255 arguments")
 			}
+			var name Token
+			if p.check(IDENTIFIER) && p.checkNext(COLON) {
+				name = p.advance()
+				p.advance()
+				named = true
+			} else if named {
+				Error(p.peek(), "Positional argument can't follow a named one")
+			}
 			arg, err := p.expression()
 			if err != nil {
 				return nil, err
 			}
 			arguments = append(arguments, arg)
+			names = append(names, name)
 			if !p.match(COMMA) {
 				break
 			}
@@ -233,7 +425,10 @@ func (p *Parser) finishCall(callee Expr) (Expr, ParserError) {
 		return nil, err
 	}
 
-	return NewCallExpr(callee, paren, arguments), nil
+	if !named {
+		names = nil
+	}
+	return NewCallExpr(callee, paren, arguments, names), nil
 }
 
 func (p *Parser) primary() (Expr, ParserError) {
@@ -246,8 +441,32 @@ func (p *Parser) primary() (Expr, ParserError) {
 		return NewLiteralExpr(nil), nil
 	case p.match(NUMBER, STRING):
 		return NewLiteralExpr(p.previous().Literal), nil
+	case p.match(INTERPOLATION):
+		return p.interpolation()
+	case p.match(SUPER):
+		keyword := p.previous()
+		if _, err := p.consume(DOT, "Expect '.' after 'super'"); err != nil {
+			return nil, err
+		}
+		method, err := p.consume(IDENTIFIER, "Expect superclass method name")
+		if err != nil {
+			return nil, err
+		}
+		return NewSuperExpr(keyword, method), nil
+	case p.match(THIS):
+		return NewThisExpr(p.previous()), nil
 	case p.match(IDENTIFIER):
 		return NewVariableExpr(p.previous()), nil
+	case p.match(FUN):
+		return p.lambda()
+	case p.check(LEFT_PAREN) && p.isArrowFunction():
+		p.advance()
+		return p.arrowFunction()
+	case p.match(LEFT_BRACKET):
+		return p.list()
+	case p.match(LEFT_BRACE):
+		// a '{' starting a statement is a block, so this is always a map
+		return p.mapLiteral()
 	case p.match(LEFT_PAREN):
 		expr, err := p.expression()
 		if err != nil {
@@ -263,6 +482,159 @@ func (p *Parser) primary() (Expr, ParserError) {
 	}
 }
 
+// fun (a, b) { ... }
+func (p *Parser) lambda() (Expr, ParserError) {
+	keyword := p.previous()
+	if _, err := p.consume(LEFT_PAREN, "Expect '(' after 'fun'"); err != nil {
+		return nil, err
+	}
+	parameters, defaults, rest, err := p.parameters()
+	if err != nil {
+		return nil, err
+	}
+	body, generator, err := p.functionBody("function")
+	if err != nil {
+		return nil, err
+	}
+	return NewLambdaExpr(NewFunctionStmt(anonymousName(keyword), parameters, defaults, rest, body, generator, "")), nil
+}
+
+// (a, b) => a + b is a shorthand for fun (a, b) { return a + b; }, the body can
+// be a block as well
+func (p *Parser) arrowFunction() (Expr, ParserError) {
+	parameters, defaults, rest, err := p.parameters()
+	if err != nil {
+		return nil, err
+	}
+	arrow, err := p.consume(ARROW, "Expect '=>' after parameters")
+	if err != nil {
+		return nil, err
+	}
+
+	var body []Stmt
+	generator := false
+	if p.check(LEFT_BRACE) {
+		body, generator, err = p.functionBody("function")
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		value, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		body = []Stmt{NewReturnStmt(arrow, value)}
+	}
+	return NewLambdaExpr(NewFunctionStmt(anonymousName(arrow), parameters, defaults, rest, body, generator, "")), nil
+}
+
+// isArrowFunction looks ahead whether the '(' at the current token starts the
+// parameter list of an arrow function rather than a grouping, i.e. whether the
+// matching ')' is followed by '=>'
+func (p *Parser) isArrowFunction() bool {
+	depth := 0
+	for j := p.current; ; j++ {
+		switch p.tokens[j].TokenType {
+		case LEFT_PAREN, LEFT_BRACKET, LEFT_BRACE:
+			depth++
+		case RIGHT_PAREN, RIGHT_BRACKET, RIGHT_BRACE:
+			depth--
+			if depth == 0 {
+				return p.tokens[j+1].TokenType == ARROW
+			}
+		case EOF:
+			return false
+		}
+	}
+}
+
+// anonymous functions have no name unless they are directly assigned to a
+// variable or a property, see nameAnonymousFunction
+func anonymousName(token Token) Token {
+	return Token{TokenType: IDENTIFIER, Lexeme: "", Literal: nil, Line: token.Line, File: token.File}
+}
+
+func nameAnonymousFunction(value Expr, name Token) {
+	if lambda, ok := value.(*LambdaExpr); ok && lambda.function.name.Lexeme == "" {
+		lambda.function.name = name
+	}
+}
+
+// "a ${b} c ${d} e" is scanned as INTERPOLATION("a ") b INTERPOLATION(" c ") d STRING(" e")
+func (p *Parser) interpolation() (Expr, ParserError) {
+	var parts []Expr
+	for {
+		parts = append(parts, NewLiteralExpr(p.previous().Literal))
+		expr, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, expr)
+		if !p.match(INTERPOLATION) {
+			break
+		}
+	}
+	end, err := p.consume(STRING, "Expect '}' after interpolated expression")
+	if err != nil {
+		return nil, err
+	}
+	parts = append(parts, NewLiteralExpr(end.Literal))
+	return NewInterpolationExpr(parts), nil
+}
+
+func (p *Parser) list() (Expr, ParserError) {
+	bracket := p.previous()
+	var elements []Expr
+	if !p.check(RIGHT_BRACKET) {
+		for {
+			element, err := p.expression()
+			if err != nil {
+				return nil, err
+			}
+			elements = append(elements, element)
+			if !p.match(COMMA) {
+				break
+			}
+		}
+	}
+	if _, err := p.consume(RIGHT_BRACKET, "Expect ']' after list elements"); err != nil {
+		return nil, err
+	}
+	return NewListExpr(bracket, elements), nil
+}
+
+func (p *Parser) mapLiteral() (Expr, ParserError) {
+	brace := p.previous()
+	var keys []Expr
+	var values []Expr
+	if !p.check(RIGHT_BRACE) {
+		for {
+			key, err := p.expression()
+			if err != nil {
+				return nil, err
+			}
+			p.skipLineEnds()
+			if _, err := p.consume(COLON, "Expect ':' after map key"); err != nil {
+				return nil, err
+			}
+			value, err := p.expression()
+			if err != nil {
+				return nil, err
+			}
+			keys = append(keys, key)
+			values = append(values, value)
+			p.skipLineEnds()
+			if !p.match(COMMA) {
+				break
+			}
+		}
+	}
+	if _, err := p.consume(RIGHT_BRACE, "Expect '}' after map entries"); err != nil {
+		return nil, err
+	}
+	return NewMapExpr(brace, keys, values), nil
+}
+
 // -----------------------------------------------------------------
 
 func (p *Parser) consume(tokenType TokenType, message string) (Token, ParserError) {
@@ -274,6 +646,27 @@ func (p *Parser) consume(tokenType TokenType, message string) (Token, ParserErro
 
 }
 
+// consumeTerminator consumes the ';' or the line end (see
+// Scanner.SetOptionalSemicolons) that ends a statement
+func (p *Parser) consumeTerminator(message string) (Token, ParserError) {
+	if p.checkTerminator() {
+		return p.advance(), nil
+	}
+	return Token{}, p.error(message)
+}
+
+func (p *Parser) checkTerminator() bool {
+	return p.check(SEMICOLON) || p.check(EOL)
+}
+
+// skipLineEnds skips the line ends where they don't end a statement: before a
+// statement, before a '{' or a keyword going on with the statement like
+// 'else', and around the entries of a map
+func (p *Parser) skipLineEnds() {
+	for p.match(EOL) {
+	}
+}
+
 func (p *Parser) match(types ...TokenType) bool {
 	for _, tokenType := range types {
 		if p.check(tokenType) {
@@ -291,6 +684,13 @@ func (p *Parser) check(tokenType TokenType) bool {
 	return p.peek().TokenType == tokenType
 }
 
+func (p *Parser) checkNext(tokenType TokenType) bool {
+	if p.isAtEnd() {
+		return false
+	}
+	return p.tokens[p.current+1].TokenType == tokenType
+}
+
 func (p *Parser) advance() Token {
 	// TODO why this way? What is isAtEnd is true -- advnace then returns the last token?
 	if !p.isAtEnd() {
@@ -311,16 +711,21 @@ func (p *Parser) previous() Token {
 	return p.tokens[p.current-1]
 }
 
+// docComment returns the doc comment written right before the token, or ""
+func (p *Parser) docComment(token Token) string {
+	return p.docs[token.Offset]
+}
+
 func (p *Parser) synchronize() {
 	p.advance()
 
 	for !p.isAtEnd() {
-		if p.previous().TokenType == SEMICOLON {
+		if p.previous().TokenType == SEMICOLON || p.previous().TokenType == EOL {
 			return
 		}
 
 		switch p.peek().TokenType {
-		case CLASS, FUN, VAR, FOR, IF, WHILE, PRINT, RETURN:
+		case CLASS, FUN, VAR, CONST, IMPORT, FOR, IF, MATCH, WHILE, PRINT, RETURN, BREAK, CONTINUE, TRY, THROW, YIELD:
 			return
 		default:
 			p.advance()
@@ -356,6 +761,7 @@ func (pe ParserErrorObj) GetMessage() string {
 func (p *Parser) declaration() (Stmt, ParserError) {
 	var stmt Stmt
 	var err ParserError
+	p.skipLineEnds()
 	// if p.match(FUN) {
 	// 	stmt, err = p.function("function")
 	// } else if p.match(VAR) {
@@ -364,20 +770,69 @@ func (p *Parser) declaration() (Stmt, ParserError) {
 	// 	stmt, err = p.statement()
 	// }
 	switch {
-	case p.match(FUN):
+	case p.match(CLASS):
+		stmt, err = p.classDeclaration()
+	case p.check(FUN) && p.checkNext(IDENTIFIER):
+		// otherwise it is an anonymous function in an expression statement
+		p.advance()
 		stmt, err = p.function("function")
 	case p.match(VAR):
 		stmt, err = p.varDeclaration()
+	case p.match(CONST):
+		stmt, err = p.constDeclaration()
+	case p.match(IMPORT):
+		stmt, err = p.importDeclaration()
 	default:
 		stmt, err = p.statement()
 	}
 	if err != nil {
 		p.synchronize()
+		return nil, err
 	}
-	return stmt, err
+	return stmt, nil
 }
 
-func (p *Parser) function(kind string) (Stmt, ParserError) {
+func (p *Parser) classDeclaration() (Stmt, ParserError) {
+	doc := p.docComment(p.previous())
+	name, err := p.consume(IDENTIFIER, "Expect class name")
+	if err != nil {
+		return nil, err
+	}
+
+	var superclass *VariableExpr
+	if p.match(LESS) {
+		if _, err := p.consume(IDENTIFIER, "Expect superclass name"); err != nil {
+			return nil, err
+		}
+		superclass = NewVariableExpr(p.previous())
+	}
+
+	p.skipLineEnds()
+	if _, err := p.consume(LEFT_BRACE, "Expect '{' before class body"); err != nil {
+		return nil, err
+	}
+
+	var methods []*FunctionStmt
+	for p.skipLineEnds(); !p.check(RIGHT_BRACE) && !p.isAtEnd(); p.skipLineEnds() {
+		method, err := p.function("method")
+		if err != nil {
+			return nil, err
+		}
+		methods = append(methods, method)
+	}
+
+	if _, err := p.consume(RIGHT_BRACE, "Expect '}' after class body"); err != nil {
+		return nil, err
+	}
+	return NewClassStmt(name, superclass, methods, doc), nil
+}
+
+func (p *Parser) function(kind string) (*FunctionStmt, ParserError) {
+	// a method has no 'fun' keyword, its doc comment is before its name
+	doc := p.docComment(p.previous())
+	if kind == "method" {
+		doc = p.docComment(p.peek())
+	}
 	name, err := p.consume(IDENTIFIER, "Expect "+kind+" name")
 	if err != nil {
 		return nil, err
@@ -386,41 +841,98 @@ func (p *Parser) function(kind string) (Stmt, ParserError) {
 	if err!=nil {
 		return nil, err
 	}
+	parameters, defaults, rest, err := p.parameters()
+	if err != nil {
+		return nil, err
+	}
+	body, generator, err := p.functionBody(kind)
+	if err != nil {
+		return nil, err
+	}
+	return NewFunctionStmt(name, parameters, defaults, rest, body, generator, doc), nil
+}
 
+// parameters parses the parameter list after the opening '(': the parameters
+// without a default value come first, then the ones with a default value and
+// finally an optional rest parameter collecting the remaining arguments into a
+// list. defaults has a nil for every parameter without a default value, rest
+// has an empty lexeme when there is no rest parameter.
+func (p *Parser) parameters() ([]Token, []Expr, Token, ParserError) {
 	var parameters []Token
-	if !p.check(RIGHT_BRACE) {
+	var defaults []Expr
+	var rest Token
+	if !p.check(RIGHT_PAREN) {
 		for {
 			if len(parameters) >= 255 {
 				Error(p.peek(), "Can't have more than 255 paremters")
 			}
 
+			if p.match(ELLIPSIS) {
+				par, err := p.consume(IDENTIFIER, "Expect parameter name after '...'")
+				if err != nil {
+					return nil, nil, rest, err
+				}
+				rest = par
+				if !p.check(RIGHT_PAREN) {
+					return nil, nil, rest, p.error("Rest parameter must be the last one")
+				}
+				break
+			}
+
 			par, err := p.consume(IDENTIFIER, "Expect parameter name")
 			if err != nil {
-				return nil, err
+				return nil, nil, rest, err
+			}
+			var value Expr
+			if p.match(EQUAL) {
+				value, err = p.expression()
+				if err != nil {
+					return nil, nil, rest, err
+				}
+			} else if len(defaults) > 0 && defaults[len(defaults)-1] != nil {
+				Error(par, "Parameter without a default value can't follow one with a default value")
 			}
 			parameters = append(parameters, par)
+			defaults = append(defaults, value)
 			if !p.match(COMMA) {
 				break
 			}
 		}
 	}
-	_, err = p.consume(RIGHT_PAREN, "Expect ')' after parameters")
+	_, err := p.consume(RIGHT_PAREN, "Expect ')' after parameters")
 	if err != nil {
-		return nil, err
+		return nil, nil, rest, err
 	}
+	return parameters, defaults, rest, nil
+}
 
-	_, err = p.consume(LEFT_BRACE, "Expect '{' before "+kind+" body")
+// functionBody parses the body of a function and tells whether the function
+// is a generator
+func (p *Parser) functionBody(kind string) ([]Stmt, bool, ParserError) {
+	p.skipLineEnds()
+	_, err := p.consume(LEFT_BRACE, "Expect '{' before "+kind+" body")
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
+
+	// a loop around the declaration does not make 'break' valid inside the body
+	enclosingLoopDepth := p.loopDepth
+	p.loopDepth = 0
+	// a 'yield' in a nested function does not make this one a generator
+	enclosingYields := p.yields
+	p.yields = false
 	body, err := p.block()
+	generator := p.yields
+	p.loopDepth = enclosingLoopDepth
+	p.yields = enclosingYields
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
-	return NewFunctionStmt(name, parameters, body), nil
+	return body, generator, nil
 }
 
 func (p *Parser) varDeclaration() (Stmt, ParserError) {
+	doc := p.docComment(p.previous())
 	name, err := p.consume(IDENTIFIER, "Expect variable name")
 	if err != nil {
 		return nil, err
@@ -431,17 +943,67 @@ func (p *Parser) varDeclaration() (Stmt, ParserError) {
 		if err != nil {
 			return nil, err
 		}
+		nameAnonymousFunction(initializer, name)
 	}
 
-	_, err = p.consume(SEMICOLON, "Expect ';' after variable declaration")
+	_, err = p.consumeTerminator("Expect ';' after variable declaration")
+	if err != nil {
+		return nil, err
+	}
+
+	return NewVarStmt(name, initializer, doc), nil
+}
+
+// unlike a variable a constant must be initialized, it can't be assigned later
+func (p *Parser) constDeclaration() (Stmt, ParserError) {
+	doc := p.docComment(p.previous())
+	name, err := p.consume(IDENTIFIER, "Expect constant name")
+	if err != nil {
+		return nil, err
+	}
+	_, err = p.consume(EQUAL, "Expect '=' after constant name")
+	if err != nil {
+		return nil, err
+	}
+	initializer, err := p.expression()
 	if err != nil {
 		return nil, err
 	}
+	nameAnonymousFunction(initializer, name)
 
-	return NewVarStmt(name, initializer), nil
+	_, err = p.consumeTerminator("Expect ';' after constant declaration")
+	if err != nil {
+		return nil, err
+	}
+	return NewConstStmt(name, initializer, doc), nil
+}
+
+// import "path/to/module.glox" as name;
+//
+// 'as' is not a keyword, it is only recognized at this position
+func (p *Parser) importDeclaration() (Stmt, ParserError) {
+	keyword := p.previous()
+	path, err := p.consume(STRING, "Expect module path after 'import'")
+	if err != nil {
+		return nil, err
+	}
+	if !p.check(IDENTIFIER) || p.peek().Lexeme != "as" {
+		return nil, p.error("Expect 'as' after module path")
+	}
+	p.advance()
+	name, err := p.consume(IDENTIFIER, "Expect module name after 'as'")
+	if err != nil {
+		return nil, err
+	}
+	_, err = p.consumeTerminator("Expect ';' after import")
+	if err != nil {
+		return nil, err
+	}
+	return NewImportStmt(keyword, path, name), nil
 }
 
 func (p *Parser) statement() (Stmt, ParserError) {
+	p.skipLineEnds()
 	if p.match(FOR) {
 		return p.forStatement()
 	}
@@ -464,31 +1026,254 @@ func (p *Parser) statement() (Stmt, ParserError) {
 	if p.match(RETURN) {
 		return p.returnStatement()
 	}
+	if p.match(BREAK) {
+		return p.breakStatement()
+	}
+	if p.match(CONTINUE) {
+		return p.continueStatement()
+	}
+	if p.match(TRY) {
+		return p.tryStatement()
+	}
+	if p.match(THROW) {
+		return p.throwStatement()
+	}
+	if p.match(MATCH) {
+		return p.matchStatement()
+	}
+	if p.match(YIELD) {
+		return p.yieldStatement()
+	}
 
 	return p.expressionStatement()
 }
 
+// match (subject) {
+//   case pattern ( "," pattern )* ( "if" guard )? => statement
+//   ...
+// }
+func (p *Parser) matchStatement() (Stmt, ParserError) {
+	keyword := p.previous()
+	if _, err := p.consume(LEFT_PAREN, "Expect '(' after 'match'"); err != nil {
+		return nil, err
+	}
+	subject, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.consume(RIGHT_PAREN, "Expect ')' after match subject"); err != nil {
+		return nil, err
+	}
+	p.skipLineEnds()
+	if _, err := p.consume(LEFT_BRACE, "Expect '{' before match cases"); err != nil {
+		return nil, err
+	}
+
+	var arms []*MatchArm
+	for p.skipLineEnds(); !p.check(RIGHT_BRACE) && !p.isAtEnd(); p.skipLineEnds() {
+		if _, err := p.consume(CASE, "Expect 'case'"); err != nil {
+			return nil, err
+		}
+		pattern, err := p.pattern()
+		if err != nil {
+			return nil, err
+		}
+		var guard Expr
+		if p.match(IF) {
+			guard, err = p.expression()
+			if err != nil {
+				return nil, err
+			}
+		}
+		if _, err := p.consume(ARROW, "Expect '=>' after pattern"); err != nil {
+			return nil, err
+		}
+		body, err := p.statement()
+		if err != nil {
+			return nil, err
+		}
+		arms = append(arms, NewMatchArm(pattern, guard, body))
+	}
+
+	if _, err := p.consume(RIGHT_BRACE, "Expect '}' after match cases"); err != nil {
+		return nil, err
+	}
+	return NewMatchStmt(keyword, subject, arms), nil
+}
+
+// pattern parses comma separated alternatives, a single pattern is not
+// wrapped in an AlternativePattern
+func (p *Parser) pattern() (Pattern, ParserError) {
+	pattern, err := p.simplePattern()
+	if err != nil {
+		return nil, err
+	}
+	if !p.check(COMMA) {
+		return pattern, nil
+	}
+
+	alternatives := []Pattern{pattern}
+	for p.match(COMMA) {
+		pattern, err := p.simplePattern()
+		if err != nil {
+			return nil, err
+		}
+		alternatives = append(alternatives, pattern)
+	}
+	return NewAlternativePattern(alternatives), nil
+}
+
+func (p *Parser) simplePattern() (Pattern, ParserError) {
+	switch {
+	case p.match(FALSE):
+		return NewLiteralPattern(p.previous(), false), nil
+	case p.match(TRUE):
+		return NewLiteralPattern(p.previous(), true), nil
+	case p.match(NIL):
+		return NewLiteralPattern(p.previous(), nil), nil
+	case p.match(NUMBER, STRING):
+		return NewLiteralPattern(p.previous(), p.previous().Literal), nil
+	case p.match(MINUS):
+		number, err := p.consume(NUMBER, "Expect number after '-' in pattern")
+		if err != nil {
+			return nil, err
+		}
+		return NewLiteralPattern(number, -number.Literal.(float64)), nil
+	case p.match(IDENTIFIER):
+		if p.previous().Lexeme == "_" {
+			return NewWildcardPattern(p.previous()), nil
+		}
+		return NewBindingPattern(p.previous()), nil
+	}
+	return nil, p.error("Expect pattern")
+}
+
+func (p *Parser) yieldStatement() (Stmt, ParserError) {
+	keyword := p.previous()
+	p.yields = true
+	var value Expr
+	if !p.checkTerminator() {
+		var err ParserError
+		value, err = p.expression()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if _, err := p.consumeTerminator("Expect ';' after yield value"); err != nil {
+		return nil, err
+	}
+	return NewYieldStmt(keyword, value), nil
+}
+
 func (p *Parser) returnStatement() (Stmt, ParserError) {
 	keyword := p.previous()
 	var value Expr
 	var err ParserError
-	if !p.check(SEMICOLON) {
+	if !p.checkTerminator() {
 		value, err = p.expression()
 		if err!=nil {
 			return nil, err
 		}
 	}
-	_, err = p.consume(SEMICOLON, "Expect ';' after return value")
+	_, err = p.consumeTerminator("Expect ';' after return value")
 	if err!=nil {
 		return nil, err
 	}
 	return NewReturnStmt(keyword, value), nil
 }
 
+func (p *Parser) breakStatement() (Stmt, ParserError) {
+	keyword := p.previous()
+	if p.loopDepth == 0 {
+		// report, but keep on parsing
+		Error(keyword, "Can't use 'break' outside of a loop")
+	}
+	if _, err := p.consumeTerminator("Expect ';' after 'break'"); err != nil {
+		return nil, err
+	}
+	return NewBreakStmt(keyword), nil
+}
+
+func (p *Parser) continueStatement() (Stmt, ParserError) {
+	keyword := p.previous()
+	if p.loopDepth == 0 {
+		Error(keyword, "Can't use 'continue' outside of a loop")
+	}
+	if _, err := p.consumeTerminator("Expect ';' after 'continue'"); err != nil {
+		return nil, err
+	}
+	return NewContinueStmt(keyword), nil
+}
+
+func (p *Parser) tryStatement() (Stmt, ParserError) {
+	if _, err := p.consume(LEFT_BRACE, "Expect '{' after 'try'"); err != nil {
+		return nil, err
+	}
+	tryBlock, err := p.block()
+	if err != nil {
+		return nil, err
+	}
+
+	// catchName stays empty when there is no catch clause
+	var catchName Token
+	var catchBlock []Stmt
+	p.skipLineEnds()
+	if p.match(CATCH) {
+		if _, err := p.consume(LEFT_PAREN, "Expect '(' after 'catch'"); err != nil {
+			return nil, err
+		}
+		catchName, err = p.consume(IDENTIFIER, "Expect error variable name")
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.consume(RIGHT_PAREN, "Expect ')' after error variable name"); err != nil {
+			return nil, err
+		}
+		p.skipLineEnds()
+		if _, err := p.consume(LEFT_BRACE, "Expect '{' after catch clause"); err != nil {
+			return nil, err
+		}
+		catchBlock, err = p.block()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var finallyBlock []Stmt
+	p.skipLineEnds()
+	hasFinally := p.match(FINALLY)
+	if hasFinally {
+		if _, err := p.consume(LEFT_BRACE, "Expect '{' after 'finally'"); err != nil {
+			return nil, err
+		}
+		finallyBlock, err = p.block()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if catchName.Lexeme == "" && !hasFinally {
+		return nil, p.error("Expect 'catch' or 'finally' after try block")
+	}
+	return NewTryStmt(tryBlock, catchName, catchBlock, finallyBlock), nil
+}
+
+func (p *Parser) throwStatement() (Stmt, ParserError) {
+	keyword := p.previous()
+	value, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.consumeTerminator("Expect ';' after thrown value"); err != nil {
+		return nil, err
+	}
+	return NewThrowStmt(keyword, value), nil
+}
+
 func (p *Parser) block() ([]Stmt, ParserError) {
 	var statements []Stmt
 
-	for !p.check(RIGHT_BRACE) && !p.isAtEnd() {
+	for p.skipLineEnds(); !p.check(RIGHT_BRACE) && !p.isAtEnd(); p.skipLineEnds() {
 		dclr, err := p.declaration()
 		if err != nil {
 			return statements, err
@@ -507,7 +1292,7 @@ func (p *Parser) printStatement() (Stmt, ParserError) {
 	if err != nil {
 		return nil, err
 	}
-	p.consume(SEMICOLON, "Expect ';' after value.")
+	p.consumeTerminator("Expect ';' after value.")
 	return NewPrintStmt(value), nil
 }
 
@@ -516,7 +1301,7 @@ func (p *Parser) expressionStatement() (Stmt, ParserError) {
 	if err != nil {
 		return nil, err
 	}
-	p.consume(SEMICOLON, "Expect ';' after value.")
+	p.consumeTerminator("Expect ';' after value.")
 	return NewExpressionStmt(expr), nil
 
 }
@@ -542,6 +1327,7 @@ func (p *Parser) ifStatement() (Stmt, ParserError) {
 		return nil, err
 	}
 	var elseBranch Stmt
+	p.skipLineEnds()
 	if p.match(ELSE) {
 		elseBranch, err = p.statement()
 		if err != nil {
@@ -565,15 +1351,52 @@ func (p *Parser) whileStatement() (Stmt, ParserError) {
 		return nil, err
 	}
 
+	p.loopDepth++
 	body, err := p.statement()
+	p.loopDepth--
 	if err != nil {
 		return nil, err
 	}
 
-	return NewWhileStmt(condition, body), nil
+	return NewWhileStmt(condition, body, nil), nil
 
 }
 
+// isForIn looks ahead whether the for clauses are 'var name in' (or just
+// 'name in') rather than the classic three clauses
+func (p *Parser) isForIn() bool {
+	j := p.current
+	if p.tokens[j].TokenType == VAR {
+		j++
+	}
+	return p.tokens[j].TokenType == IDENTIFIER && p.tokens[j+1].TokenType == IN
+}
+
+// for (var name in iterable) body
+//
+// the loop variable is a new variable in every iteration, closures created in
+// the body each see their own value
+func (p *Parser) forInStatement(keyword Token) (Stmt, ParserError) {
+	p.match(VAR)
+	name := p.advance()
+	p.advance()
+	iterable, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.consume(RIGHT_PAREN, "Expect ')' after for clauses"); err != nil {
+		return nil, err
+	}
+
+	p.loopDepth++
+	body, err := p.statement()
+	p.loopDepth--
+	if err != nil {
+		return nil, err
+	}
+	return NewForInStmt(keyword, name, iterable, body), nil
+}
+
 func (p *Parser) forStatement() (Stmt, ParserError) {
 	// implemented by desugaring the fancy for loop into a sequence of statements:
 	// for (initializer; condition; increment) body
@@ -581,18 +1404,23 @@ func (p *Parser) forStatement() (Stmt, ParserError) {
 	// initializer;
 	// while (condition) {
 	//   body;
-	//   increment;
-	// }
+	// } then increment;
+	//
+	// The increment is kept apart from the body, so that 'continue' still runs it.
 	var initializer Stmt
 	var condition Expr
 	var increment Expr
 	var body Stmt
 	var err ParserError
 
+	keyword := p.previous()
 	_, err = p.consume(LEFT_PAREN, "Expect '(' after 'for'")
 	if err != nil {
 		return nil, err
 	}
+	if p.isForIn() {
+		return p.forInStatement(keyword)
+	}
 
 	switch {
 	case p.match(SEMICOLON):
@@ -630,24 +1458,17 @@ func (p *Parser) forStatement() (Stmt, ParserError) {
 		return nil, err
 	}
 
+	p.loopDepth++
 	body, err = p.statement()
+	p.loopDepth--
 	if err != nil {
 		return nil, err
 	}
 
-	if increment != nil {
-		body = NewBlockStmt(
-			[]Stmt{
-				body,
-				NewExpressionStmt(increment),
-			},
-		)
-	}
-
 	if condition == nil {
 		condition = NewLiteralExpr(true)
 	}
-	body = NewWhileStmt(condition, body)
+	body = NewWhileStmt(condition, body, increment)
 	if initializer != nil {
 		body = NewBlockStmt(
 			[]Stmt{
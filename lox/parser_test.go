@@ -0,0 +1,149 @@
+package lox
+
+import "testing"
+
+func TestDocCommentsAttached(t *testing.T) {
+	source := `
+/// Shapes have an area.
+/// Subclasses override it.
+class Shape {
+  /// Zero for the plain shape.
+  area() { return 0; }
+  perimeter() { return 0; }
+}
+/// The answer.
+const answer = 42;
+/// Not attached to a statement
+print answer;
+var undocumented;
+/// Doubles.
+fun double(x) { return 2 * x; }
+`
+	scanner := NewScanner(source)
+	statements := NewParser(scanner.ScanTokens()).Parse()
+	if len(statements) != 5 {
+		t.Fatalf("failed: parsed %d statements", len(statements))
+	}
+
+	class := statements[0].(*ClassStmt)
+	docs := map[string]string{
+		"class":     class.GetDoc(),
+		"area":      class.methods[0].GetDoc(),
+		"perimeter": class.methods[1].GetDoc(),
+		"const":     statements[1].(*ConstStmt).GetDoc(),
+		"var":       statements[3].(*VarStmt).GetDoc(),
+		"fun":       statements[4].(*FunctionStmt).GetDoc(),
+	}
+	expected := map[string]string{
+		"class":     "Shapes have an area.\nSubclasses override it.",
+		"area":      "Zero for the plain shape.",
+		"perimeter": "",
+		"const":     "The answer.",
+		"var":       "",
+		"fun":       "Doubles.",
+	}
+	for name, doc := range expected {
+		if docs[name] != doc {
+			t.Errorf("failed: doc of %s is %q, expected %q", name, docs[name], doc)
+		}
+	}
+}
+
+func TestOptionalSemicolons(t *testing.T) {
+	defer func() { HadError = false }()
+
+	source := `
+var a = 1
+if (a > 0)
+{
+  print a
+}
+else print 0
+fun f(x) { return x }
+var m = {
+  "a": 1
+}
+try {
+  f(a)
+}
+catch (e) {}
+print f(a); print a
+print apply(fun (x) { return x + 1 })
+var doubled = map([1, 2], (x) => { return x * 2 })
+var functions = [fun (x) { return x }, (x) => { return x }]
+`
+	scanner := NewScanner(source)
+	scanner.SetOptionalSemicolons(true)
+	HadError = false
+	statements := NewParser(scanner.ScanTokens()).Parse()
+	if HadError || len(statements) != 10 {
+		t.Fatalf("failed: parsed %d statements", len(statements))
+	}
+	if _, ok := statements[1].(*IfStmt).elseBranch.(*PrintStmt); !ok {
+		t.Errorf("failed: else branch is %v", statements[1].(*IfStmt).elseBranch)
+	}
+
+	// strict mode is unchanged
+	scanner = NewScanner("var a = 1\nvar b = 2;")
+	NewParser(scanner.ScanTokens()).Parse()
+	if !HadError {
+		t.Errorf("failed: missing semicolon accepted in strict mode")
+	}
+}
+
+func TestDocCommentsOnlyRightBeforeDeclaration(t *testing.T) {
+	for _, source := range []string{
+		"/// Separated by a blank line.\n\nvar x;",
+		"/// Separated by a block comment.\n/* note */\nvar x;",
+		"/// Not documenting the expression.\nx;\nvar x;",
+	} {
+		scanner := NewScanner(source)
+		statements := NewParser(scanner.ScanTokens()).Parse()
+		last := statements[len(statements)-1].(*VarStmt)
+		if last.GetDoc() != "" {
+			t.Errorf("failed: %q attached %q", source, last.GetDoc())
+		}
+	}
+}
+
+func TestDocCommentsWithOptionalSemicolons(t *testing.T) {
+	source := `
+var x = 1 /// Doubles.
+fun double(x) { return 2 * x }
+/// Halves.
+fun half(x) { return x / 2 }
+class Shape {
+  /// Zero for the plain shape.
+  area() { return 0 }
+}
+/// Not attached to a statement
+print x
+var y
+`
+	scanner := NewScanner(source)
+	scanner.SetOptionalSemicolons(true)
+	statements := NewParser(scanner.ScanTokens()).Parse()
+	if HadError || len(statements) != 6 {
+		t.Fatalf("failed: parsed %d statements", len(statements))
+	}
+
+	docs := map[string]string{
+		"var x":  statements[0].(*VarStmt).GetDoc(),
+		"double": statements[1].(*FunctionStmt).GetDoc(),
+		"half":   statements[2].(*FunctionStmt).GetDoc(),
+		"area":   statements[3].(*ClassStmt).methods[0].GetDoc(),
+		"var y":  statements[5].(*VarStmt).GetDoc(),
+	}
+	expected := map[string]string{
+		"var x":  "",
+		"double": "Doubles.",
+		"half":   "Halves.",
+		"area":   "Zero for the plain shape.",
+		"var y":  "",
+	}
+	for name, doc := range expected {
+		if docs[name] != doc {
+			t.Errorf("failed: doc of %s is %q, expected %q", name, docs[name], doc)
+		}
+	}
+}
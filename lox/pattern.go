@@ -0,0 +1,72 @@
+// generated by generate_ast tool. Do not edit.
+package lox
+
+type PatternVisitor interface {
+  VisitAlternativePattern(pattern *AlternativePattern) (any, LoxError)
+  VisitBindingPattern(pattern *BindingPattern) (any, LoxError)
+  VisitLiteralPattern(pattern *LiteralPattern) (any, LoxError)
+  VisitWildcardPattern(pattern *WildcardPattern) (any, LoxError)
+}
+
+type Pattern interface {
+  Accept(visitor PatternVisitor) (any, LoxError)
+}
+
+//  -------------------------------------------------------------
+type AlternativePattern struct {
+  alternatives []Pattern
+}
+
+func NewAlternativePattern(alternatives []Pattern) *AlternativePattern {
+  return &AlternativePattern{
+    alternatives:alternatives,
+  }
+}
+
+func (c *AlternativePattern) Accept(visitor PatternVisitor) (any, LoxError) {
+  return visitor.VisitAlternativePattern(c)
+}
+//  -------------------------------------------------------------
+type BindingPattern struct {
+  name Token
+}
+
+func NewBindingPattern(name Token) *BindingPattern {
+  return &BindingPattern{
+    name:name,
+  }
+}
+
+func (c *BindingPattern) Accept(visitor PatternVisitor) (any, LoxError) {
+  return visitor.VisitBindingPattern(c)
+}
+//  -------------------------------------------------------------
+type LiteralPattern struct {
+  token Token
+  value any
+}
+
+func NewLiteralPattern(token Token, value any) *LiteralPattern {
+  return &LiteralPattern{
+    token:token,
+    value:value,
+  }
+}
+
+func (c *LiteralPattern) Accept(visitor PatternVisitor) (any, LoxError) {
+  return visitor.VisitLiteralPattern(c)
+}
+//  -------------------------------------------------------------
+type WildcardPattern struct {
+  underscore Token
+}
+
+func NewWildcardPattern(underscore Token) *WildcardPattern {
+  return &WildcardPattern{
+    underscore:underscore,
+  }
+}
+
+func (c *WildcardPattern) Accept(visitor PatternVisitor) (any, LoxError) {
+  return visitor.VisitWildcardPattern(c)
+}
@@ -0,0 +1,19 @@
+package lox
+
+// prelude is Lox code every new interpreter runs before any user code
+const prelude = `
+class Error {
+  init(message) {
+    this.message = message;
+  }
+}
+`
+
+func (i *Interpreter) loadPrelude() {
+	scanner := NewScanner(prelude)
+	statements := NewParser(scanner.ScanTokens()).Parse()
+	NewResolver(i).Resolve(statements)
+	i.Interpret(statements)
+
+	i.errorClass = i.globals.Values["Error"].(*LoxClass)
+}
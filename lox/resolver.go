@@ -0,0 +1,547 @@
+package lox
+
+// static analysis pass run between the parser and the interpreter.
+//
+// For every local variable access it works out how many scopes away the
+// binding lives and tells the interpreter, so that the lookup at runtime
+// goes straight to the right environment. Globals are not tracked; whatever
+// is not found in a local scope is looked up in the globals.
+
+type functionType int
+
+const (
+	noFunction functionType = iota
+	inFunction
+	inMethod
+	inInitializer
+)
+
+type classType int
+
+const (
+	noClass classType = iota
+	inClass
+	inSubclass
+)
+
+type Resolver struct {
+	interpreter *Interpreter
+	// each scope maps a variable name to whether its initializer has been
+	// resolved already (false = declared, true = defined)
+	scopes []map[string]bool
+	// names declared with 'const' in each scope and at the top level
+	constants       []map[string]bool
+	globalConstants map[string]bool
+	currentFunction functionType
+	currentClass    classType
+	// whether the current function is a generator
+	inGenerator bool
+	// number of try statements around the statement in the current function
+	tryDepth int
+	// alternative patterns can't bind variables, not all of them would be set
+	inAlternative bool
+}
+
+func NewResolver(interpreter *Interpreter) *Resolver {
+	return &Resolver{
+		interpreter:     interpreter,
+		scopes:          []map[string]bool{},
+		constants:       []map[string]bool{},
+		globalConstants: map[string]bool{},
+		currentFunction: noFunction,
+		currentClass:    noClass,
+	}
+}
+
+func (r *Resolver) Resolve(statements []Stmt) {
+	for _, stmt := range statements {
+		r.resolveStmt(stmt)
+	}
+}
+
+func (r *Resolver) resolveStmt(stmt Stmt) {
+	stmt.Accept(r)
+}
+
+func (r *Resolver) resolveExpr(expr Expr) {
+	expr.Accept(r)
+}
+
+func (r *Resolver) beginScope() {
+	r.scopes = append(r.scopes, map[string]bool{})
+	r.constants = append(r.constants, map[string]bool{})
+}
+
+func (r *Resolver) endScope() {
+	r.scopes = r.scopes[:len(r.scopes)-1]
+	r.constants = r.constants[:len(r.constants)-1]
+}
+
+func (r *Resolver) declare(name Token) {
+	if len(r.scopes) == 0 {
+		if r.globalConstants[name.Lexeme] {
+			Error(name, "Can't redeclare constant '"+name.Lexeme+"'.")
+		}
+		return
+	}
+	scope := r.scopes[len(r.scopes)-1]
+	if _, ok := scope[name.Lexeme]; ok {
+		Error(name, "Already a variable with this name in this scope.")
+	}
+	scope[name.Lexeme] = false
+}
+
+func (r *Resolver) define(name Token) {
+	if len(r.scopes) == 0 {
+		return
+	}
+	r.scopes[len(r.scopes)-1][name.Lexeme] = true
+}
+
+func (r *Resolver) defineConstant(name Token) {
+	if len(r.scopes) == 0 {
+		r.globalConstants[name.Lexeme] = true
+		return
+	}
+	r.define(name)
+	r.constants[len(r.constants)-1][name.Lexeme] = true
+}
+
+// checkAssignment reports assignments to the constants known at this point,
+// the ones that are not (e.g. globals declared later) are caught at runtime
+func (r *Resolver) checkAssignment(name Token) {
+	for j := len(r.scopes) - 1; j >= 0; j-- {
+		if _, ok := r.scopes[j][name.Lexeme]; ok {
+			if r.constants[j][name.Lexeme] {
+				Error(name, "Can't assign to constant '"+name.Lexeme+"'.")
+			}
+			return
+		}
+	}
+	if r.globalConstants[name.Lexeme] {
+		Error(name, "Can't assign to constant '"+name.Lexeme+"'.")
+	}
+}
+
+func (r *Resolver) resolveLocal(expr Expr, name Token) {
+	for j := len(r.scopes) - 1; j >= 0; j-- {
+		if _, ok := r.scopes[j][name.Lexeme]; ok {
+			r.interpreter.resolve(expr, len(r.scopes)-1-j)
+			return
+		}
+	}
+	// not found: assume it is a global
+}
+
+func (r *Resolver) resolveFunction(function *FunctionStmt, kind functionType) {
+	enclosingFunction := r.currentFunction
+	r.currentFunction = kind
+	enclosingGenerator := r.inGenerator
+	r.inGenerator = function.generator
+	enclosingTryDepth := r.tryDepth
+	r.tryDepth = 0
+
+	r.beginScope()
+	for j, param := range function.params {
+		r.declare(param)
+		// a default value can use the parameters before it
+		if function.defaults[j] != nil {
+			r.resolveExpr(function.defaults[j])
+		}
+		r.define(param)
+	}
+	if function.rest.Lexeme != "" {
+		r.declare(function.rest)
+		r.define(function.rest)
+	}
+	r.Resolve(function.body)
+	r.endScope()
+
+	r.currentFunction = enclosingFunction
+	r.inGenerator = enclosingGenerator
+	r.tryDepth = enclosingTryDepth
+}
+
+// ------------------------------------------------------------------------------------------
+func (r *Resolver) VisitBlockStmt(stmt *BlockStmt) (any, LoxError) {
+	r.beginScope()
+	r.Resolve(stmt.statements)
+	r.endScope()
+	return nil, nil
+}
+
+func (r *Resolver) VisitBreakStmt(stmt *BreakStmt) (any, LoxError) {
+	return nil, nil
+}
+
+func (r *Resolver) VisitClassStmt(stmt *ClassStmt) (any, LoxError) {
+	enclosingClass := r.currentClass
+	r.currentClass = inClass
+
+	r.declare(stmt.name)
+	r.define(stmt.name)
+
+	if stmt.superclass != nil {
+		if stmt.superclass.name.Lexeme == stmt.name.Lexeme {
+			Error(stmt.superclass.name, "A class can't inherit from itself.")
+		}
+		r.currentClass = inSubclass
+		r.resolveExpr(stmt.superclass)
+
+		// 'super' lives in its own scope around the one holding 'this'
+		r.beginScope()
+		r.scopes[len(r.scopes)-1]["super"] = true
+	}
+
+	// methods are closures over a scope that holds 'this'
+	r.beginScope()
+	r.scopes[len(r.scopes)-1]["this"] = true
+	for _, method := range stmt.methods {
+		kind := inMethod
+		if method.name.Lexeme == "init" {
+			kind = inInitializer
+		}
+		r.resolveFunction(method, kind)
+	}
+	r.endScope()
+
+	if stmt.superclass != nil {
+		r.endScope()
+	}
+
+	r.currentClass = enclosingClass
+	return nil, nil
+}
+
+func (r *Resolver) VisitConstStmt(stmt *ConstStmt) (any, LoxError) {
+	r.declare(stmt.name)
+	r.resolveExpr(stmt.initializer)
+	r.defineConstant(stmt.name)
+	return nil, nil
+}
+
+func (r *Resolver) VisitContinueStmt(stmt *ContinueStmt) (any, LoxError) {
+	return nil, nil
+}
+
+func (r *Resolver) VisitExpressionStmt(stmt *ExpressionStmt) (any, LoxError) {
+	r.resolveExpr(stmt.expression)
+	return nil, nil
+}
+
+func (r *Resolver) VisitForInStmt(stmt *ForInStmt) (any, LoxError) {
+	r.resolveExpr(stmt.iterable)
+	r.beginScope()
+	r.declare(stmt.name)
+	r.define(stmt.name)
+	r.resolveStmt(stmt.body)
+	r.endScope()
+	return nil, nil
+}
+
+func (r *Resolver) VisitFunctionStmt(stmt *FunctionStmt) (any, LoxError) {
+	// define eagerly so that the function can refer to itself recursively
+	r.declare(stmt.name)
+	r.define(stmt.name)
+	r.resolveFunction(stmt, inFunction)
+	return nil, nil
+}
+
+func (r *Resolver) VisitIfStmt(stmt *IfStmt) (any, LoxError) {
+	r.resolveExpr(stmt.condition)
+	r.resolveStmt(stmt.thenBranch)
+	if stmt.elseBranch != nil {
+		r.resolveStmt(stmt.elseBranch)
+	}
+	return nil, nil
+}
+
+func (r *Resolver) VisitImportStmt(stmt *ImportStmt) (any, LoxError) {
+	r.declare(stmt.name)
+	r.define(stmt.name)
+	return nil, nil
+}
+
+func (r *Resolver) VisitMatchStmt(stmt *MatchStmt) (any, LoxError) {
+	r.resolveExpr(stmt.subject)
+	for _, arm := range stmt.arms {
+		// the variables bound by the pattern live in a scope of the arm
+		r.beginScope()
+		arm.pattern.Accept(r)
+		if arm.guard != nil {
+			r.resolveExpr(arm.guard)
+		}
+		r.resolveStmt(arm.body)
+		r.endScope()
+	}
+	return nil, nil
+}
+
+func (r *Resolver) VisitPrintStmt(stmt *PrintStmt) (any, LoxError) {
+	r.resolveExpr(stmt.expression)
+	return nil, nil
+}
+
+func (r *Resolver) VisitReturnStmt(stmt *ReturnStmt) (any, LoxError) {
+	if r.currentFunction == noFunction {
+		Error(stmt.keyword, "Can't return from top-level code.")
+	}
+	if stmt.value != nil {
+		if r.currentFunction == inInitializer {
+			Error(stmt.keyword, "Can't return a value from an initializer.")
+		}
+		if r.inGenerator {
+			Error(stmt.keyword, "Can't return a value from a generator.")
+		}
+		r.resolveExpr(stmt.value)
+
+		// the function can be left before the call unless the call is to be
+		// caught or followed by a finally block
+		if _, ok := stmt.value.(*CallExpr); ok && r.tryDepth == 0 && r.currentFunction != noFunction {
+			r.interpreter.tailCalls[stmt] = true
+		}
+	}
+	return nil, nil
+}
+
+func (r *Resolver) VisitThrowStmt(stmt *ThrowStmt) (any, LoxError) {
+	r.resolveExpr(stmt.value)
+	return nil, nil
+}
+
+func (r *Resolver) VisitTryStmt(stmt *TryStmt) (any, LoxError) {
+	r.tryDepth++
+	defer func() { r.tryDepth-- }()
+
+	r.beginScope()
+	r.Resolve(stmt.tryBlock)
+	r.endScope()
+
+	if stmt.catchName.Lexeme != "" {
+		r.beginScope()
+		r.declare(stmt.catchName)
+		r.define(stmt.catchName)
+		r.Resolve(stmt.catchBlock)
+		r.endScope()
+	}
+
+	r.beginScope()
+	r.Resolve(stmt.finallyBlock)
+	r.endScope()
+	return nil, nil
+}
+
+func (r *Resolver) VisitVarStmt(stmt *VarStmt) (any, LoxError) {
+	r.declare(stmt.name)
+	if stmt.initializer != nil {
+		r.resolveExpr(stmt.initializer)
+	}
+	r.define(stmt.name)
+	return nil, nil
+}
+
+func (r *Resolver) VisitWhileStmt(stmt *WhileStmt) (any, LoxError) {
+	r.resolveExpr(stmt.condition)
+	r.resolveStmt(stmt.body)
+	if stmt.increment != nil {
+		r.resolveExpr(stmt.increment)
+	}
+	return nil, nil
+}
+
+func (r *Resolver) VisitYieldStmt(stmt *YieldStmt) (any, LoxError) {
+	switch r.currentFunction {
+	case noFunction:
+		Error(stmt.keyword, "Can't yield from top-level code.")
+	case inInitializer:
+		Error(stmt.keyword, "Can't yield from an initializer.")
+	}
+	if stmt.value != nil {
+		r.resolveExpr(stmt.value)
+	}
+	return nil, nil
+}
+
+// ------------------------------------------------------------------------------------------
+func (r *Resolver) VisitAssignmentExpr(expr *AssignmentExpr) (any, LoxError) {
+	r.checkAssignment(expr.name)
+	r.resolveExpr(expr.value)
+	r.resolveLocal(expr, expr.name)
+	return nil, nil
+}
+
+func (r *Resolver) VisitBinaryExpr(expr *BinaryExpr) (any, LoxError) {
+	r.resolveExpr(expr.left)
+	r.resolveExpr(expr.right)
+	return nil, nil
+}
+
+func (r *Resolver) VisitCallExpr(expr *CallExpr) (any, LoxError) {
+	r.resolveExpr(expr.callee)
+	for _, argument := range expr.arguments {
+		r.resolveExpr(argument)
+	}
+	return nil, nil
+}
+
+func (r *Resolver) VisitCoalesceExpr(expr *CoalesceExpr) (any, LoxError) {
+	r.resolveExpr(expr.left)
+	r.resolveExpr(expr.right)
+	return nil, nil
+}
+
+func (r *Resolver) VisitCompoundAssignmentExpr(expr *CompoundAssignmentExpr) (any, LoxError) {
+	if variable, ok := expr.target.(*VariableExpr); ok {
+		r.checkAssignment(variable.name)
+	}
+	r.resolveExpr(expr.value)
+	r.resolveExpr(expr.target)
+	return nil, nil
+}
+
+func (r *Resolver) VisitConditionalExpr(expr *ConditionalExpr) (any, LoxError) {
+	r.resolveExpr(expr.condition)
+	r.resolveExpr(expr.thenBranch)
+	r.resolveExpr(expr.elseBranch)
+	return nil, nil
+}
+
+func (r *Resolver) VisitGetExpr(expr *GetExpr) (any, LoxError) {
+	// properties are looked up dynamically, only the object is resolved
+	r.resolveExpr(expr.object)
+	return nil, nil
+}
+
+func (r *Resolver) VisitGroupingExpr(expr *GroupingExpr) (any, LoxError) {
+	r.resolveExpr(expr.expression)
+	return nil, nil
+}
+
+func (r *Resolver) VisitIncrementExpr(expr *IncrementExpr) (any, LoxError) {
+	if variable, ok := expr.target.(*VariableExpr); ok {
+		r.checkAssignment(variable.name)
+	}
+	r.resolveExpr(expr.target)
+	return nil, nil
+}
+
+func (r *Resolver) VisitIndexExpr(expr *IndexExpr) (any, LoxError) {
+	r.resolveExpr(expr.object)
+	r.resolveExpr(expr.index)
+	return nil, nil
+}
+
+func (r *Resolver) VisitIndexSetExpr(expr *IndexSetExpr) (any, LoxError) {
+	r.resolveExpr(expr.object)
+	r.resolveExpr(expr.index)
+	r.resolveExpr(expr.value)
+	return nil, nil
+}
+
+func (r *Resolver) VisitInterpolationExpr(expr *InterpolationExpr) (any, LoxError) {
+	for _, part := range expr.parts {
+		r.resolveExpr(part)
+	}
+	return nil, nil
+}
+
+func (r *Resolver) VisitLambdaExpr(expr *LambdaExpr) (any, LoxError) {
+	r.resolveFunction(expr.function, inFunction)
+	return nil, nil
+}
+
+func (r *Resolver) VisitListExpr(expr *ListExpr) (any, LoxError) {
+	for _, element := range expr.elements {
+		r.resolveExpr(element)
+	}
+	return nil, nil
+}
+
+func (r *Resolver) VisitLiteralExpr(expr *LiteralExpr) (any, LoxError) {
+	return nil, nil
+}
+
+func (r *Resolver) VisitLogicalExpr(expr *LogicalExpr) (any, LoxError) {
+	r.resolveExpr(expr.left)
+	r.resolveExpr(expr.right)
+	return nil, nil
+}
+
+func (r *Resolver) VisitMapExpr(expr *MapExpr) (any, LoxError) {
+	for j := range expr.keys {
+		r.resolveExpr(expr.keys[j])
+		r.resolveExpr(expr.values[j])
+	}
+	return nil, nil
+}
+
+func (r *Resolver) VisitSetExpr(expr *SetExpr) (any, LoxError) {
+	r.resolveExpr(expr.value)
+	r.resolveExpr(expr.object)
+	return nil, nil
+}
+
+func (r *Resolver) VisitSuperExpr(expr *SuperExpr) (any, LoxError) {
+	switch r.currentClass {
+	case noClass:
+		Error(expr.keyword, "Can't use 'super' outside of a class.")
+		return nil, nil
+	case inClass:
+		Error(expr.keyword, "Can't use 'super' in a class with no superclass.")
+		return nil, nil
+	}
+	r.resolveLocal(expr, expr.keyword)
+	return nil, nil
+}
+
+func (r *Resolver) VisitThisExpr(expr *ThisExpr) (any, LoxError) {
+	if r.currentClass == noClass {
+		Error(expr.keyword, "Can't use 'this' outside of a class.")
+		return nil, nil
+	}
+	r.resolveLocal(expr, expr.keyword)
+	return nil, nil
+}
+
+func (r *Resolver) VisitUnaryExpr(expr *UnaryExpr) (any, LoxError) {
+	r.resolveExpr(expr.right)
+	return nil, nil
+}
+
+func (r *Resolver) VisitVariableExpr(expr *VariableExpr) (any, LoxError) {
+	if len(r.scopes) > 0 {
+		if defined, ok := r.scopes[len(r.scopes)-1][expr.name.Lexeme]; ok && !defined {
+			Error(expr.name, "Can't read local variable in its own initializer.")
+		}
+	}
+	r.resolveLocal(expr, expr.name)
+	return nil, nil
+}
+
+// ------------------------------------------------------------------------------------------
+func (r *Resolver) VisitAlternativePattern(pattern *AlternativePattern) (any, LoxError) {
+	enclosing := r.inAlternative
+	r.inAlternative = true
+	for _, alternative := range pattern.alternatives {
+		alternative.Accept(r)
+	}
+	r.inAlternative = enclosing
+	return nil, nil
+}
+
+func (r *Resolver) VisitBindingPattern(pattern *BindingPattern) (any, LoxError) {
+	if r.inAlternative {
+		Error(pattern.name, "Can't bind variables in alternative patterns.")
+	}
+	r.declare(pattern.name)
+	r.define(pattern.name)
+	return nil, nil
+}
+
+func (r *Resolver) VisitLiteralPattern(pattern *LiteralPattern) (any, LoxError) {
+	return nil, nil
+}
+
+func (r *Resolver) VisitWildcardPattern(pattern *WildcardPattern) (any, LoxError) {
+	return nil, nil
+}
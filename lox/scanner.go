@@ -1,31 +1,61 @@
 package lox
 
 import (
+	"fmt"
+	"math"
+	"math/big"
+	"path/filepath"
 	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
 // ** static helper methods ** //
 
-func isDigit(c byte) bool {
+func isDigit(c rune) bool {
 	return c >= '0' && c <= '9'
 }
 
-func isAlpha(c byte) bool {
-	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c == '_'
+// identifiers start with a letter of any script or an underscore
+func isAlpha(c rune) bool {
+	return unicode.IsLetter(c) || c == '_'
 }
 
-func isAlphaNumeric(c byte) bool {
-	return isAlpha(c) || isDigit(c)
+// and go on with letters, digits and combining marks (accents written as a
+// separate code point)
+func isAlphaNumeric(c rune) bool {
+	return isAlpha(c) || unicode.IsDigit(c) || unicode.In(c, unicode.Mn, unicode.Mc)
+}
+
+func isHexDigit(c rune) bool {
+	return isDigit(c) || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
 }
 
 // ** Scanner struct with attached methods ** //
 
+// The scanner goes through the source rune by rune, start and current are
+// byte offsets into it.
 type Scanner struct {
 	source  string
 	tokens  []Token
 	start   int
 	current int
 	line    int
+	// byte offset of the start of the current line
+	lineStart int
+	// position of the token being scanned
+	startLine   int
+	startColumn int
+	// one entry per string interpolation being scanned, counting the braces
+	// opened inside of its "${ ... }" expression
+	interpolations []int
+	// file the source was read from, recorded on every token
+	file string
+	// when semicolons are optional, line ends are tokens too (see lineEnd)
+	optionalSemicolons bool
+	// the brackets open at the current position, innermost last
+	brackets []rune
 }
 
 func NewScanner(source string) Scanner {
@@ -39,14 +69,38 @@ func NewScanner(source string) Scanner {
 	return scanner
 }
 
+// SetFile tells which file the source is read from. Imports are resolved
+// relative to it, and runtime errors in another file than the script being run
+// name it.
+func (s *Scanner) SetFile(path string) {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	s.file = path
+}
+
+// SetOptionalSemicolons makes the scanner add EOL tokens which end statements
+// just like semicolons. By default semicolons are required.
+func (s *Scanner) SetOptionalSemicolons(optional bool) {
+	s.optionalSemicolons = optional
+}
+
 func (s *Scanner) ScanTokens() []Token {
 
 	for !s.isAtEnd() {
 		s.start = s.current
+		s.startLine = s.line
+		s.startColumn = s.column()
 		s.scanToken()
 	}
 
-	s.tokens = append(s.tokens, Token{TokenType: EOF, Lexeme: "", Literal: "", Line: s.line})
+	if len(s.interpolations) > 0 {
+		Emit(s.line, "Unterminated string interpolation.")
+	}
+	s.start = s.current
+	s.lineEnd()
+
+	s.tokens = append(s.tokens, Token{TokenType: EOF, Lexeme: "", Literal: "", Line: s.line, Column: s.column(), Offset: s.current, File: s.file})
 
 	return s.tokens
 }
@@ -55,25 +109,97 @@ func (s *Scanner) scanToken() {
 	c := s.advance()
 	switch c {
 	case '(':
+		s.openBracket(c)
 		s.addToken(LEFT_PAREN)
 	case ')':
+		s.closeBracket()
 		s.addToken(RIGHT_PAREN)
 	case '{':
+		s.openBracket(c)
+		if len(s.interpolations) > 0 {
+			s.interpolations[len(s.interpolations)-1]++
+		}
 		s.addToken(LEFT_BRACE)
 	case '}':
+		if len(s.interpolations) > 0 {
+			top := len(s.interpolations) - 1
+			if s.interpolations[top] == 0 {
+				// end of the embedded expression, the string literal goes on
+				s.interpolations = s.interpolations[:top]
+				s.string()
+				return
+			}
+			s.interpolations[top]--
+		}
+		// '{ return x }' needs no terminator before the closing brace, this is
+		// checked while the '{' is still the innermost bracket
+		if s.lastTokenType() != RIGHT_BRACE {
+			s.lineEnd()
+		}
+		s.closeBracket()
 		s.addToken(RIGHT_BRACE)
+	case '[':
+		s.openBracket(c)
+		s.addToken(LEFT_BRACKET)
+	case ']':
+		s.closeBracket()
+		s.addToken(RIGHT_BRACKET)
 	case ',':
 		s.addToken(COMMA)
+	case ':':
+		s.addToken(COLON)
 	case '.':
-		s.addToken(DOT)
+		if s.peek() == '.' && s.peekNext() == '.' {
+			s.advance()
+			s.advance()
+			s.addToken(ELLIPSIS)
+		} else {
+			s.addToken(DOT)
+		}
 	case '-':
-		s.addToken(MINUS)
+		switch {
+		case s.match('-'):
+			s.addToken(MINUS_MINUS)
+		case s.match('='):
+			s.addToken(MINUS_EQUAL)
+		default:
+			s.addToken(MINUS)
+		}
 	case '+':
-		s.addToken(PLUS)
+		switch {
+		case s.match('+'):
+			s.addToken(PLUS_PLUS)
+		case s.match('='):
+			s.addToken(PLUS_EQUAL)
+		default:
+			s.addToken(PLUS)
+		}
 	case ';':
 		s.addToken(SEMICOLON)
 	case '*':
-		s.addToken(STAR)
+		switch {
+		case s.match('*'):
+			s.addToken(STAR_STAR)
+		case s.match('='):
+			s.addToken(STAR_EQUAL)
+		default:
+			s.addToken(STAR)
+		}
+	case '%':
+		s.addToken(PERCENT)
+	case '?':
+		if s.match('?') {
+			s.addToken(QUESTION_QUESTION)
+		} else {
+			s.addToken(QUESTION)
+		}
+	case '~':
+		// integer division is "~/" because "//" starts a comment
+		if s.match('/') {
+			s.addToken(TILDE_SLASH)
+		} else {
+			Emit(s.line, "Unexpected character (~)")
+		}
 	case '!':
 		if s.match('=') {
 			s.addToken(BANG_EQUAL)
@@ -95,14 +221,18 @@ func (s *Scanner) scanToken() {
 	case '=':
 		if s.match('=') {
 			s.addToken(EQUAL_EQUAL)
+		} else if s.match('>') {
+			s.addToken(ARROW)
 		} else {
 			s.addToken(EQUAL)
 		}
 	case '/':
 		if s.match('/') {
-			for s.peek() != '\n' && !s.isAtEnd() {
-				s.advance()
-			}
+			s.lineComment()
+		} else if s.match('*') {
+			s.blockComment()
+		} else if s.match('=') {
+			s.addToken(SLASH_EQUAL)
 		} else {
 			s.addToken(SLASH)
 		}
@@ -110,7 +240,12 @@ func (s *Scanner) scanToken() {
 	case '\r':
 	case '\t':
 	case '\n':
-		s.line++
+		s.lineEnd()
+		// a blank line ends the doc comments before it, they document nothing
+		if strings.TrimSpace(s.source[s.lineStart:s.current]) == "" {
+			s.dropDocComments()
+		}
+		s.newline()
 	case '"':
 		s.string()
 	default:
@@ -118,45 +253,153 @@ func (s *Scanner) scanToken() {
 			s.number()
 		} else if isAlpha(c) {
 			s.identifier()
+		} else if c == utf8.RuneError {
+			Emit(s.line, "Invalid UTF-8 encoding.")
 		} else {
 			Emit(s.line, "Unexpected character ("+string(c)+")")
 		}
 	}
 }
 
-func (s *Scanner) advance() byte {
-	next := s.source[s.current]
-	s.current++
+// lineComment skips a '//' comment. A '///' comment (but not '////') is a doc
+// comment, kept as a token for the parser to attach to the declaration on the
+// next line. Doc comments followed by a blank line or a block comment are
+// dropped.
+func (s *Scanner) lineComment() {
+	doc := s.peek() == '/' && s.peekNext() != '/'
+	for s.peek() != '\n' && !s.isAtEnd() {
+		s.advance()
+	}
+	if doc {
+		text := strings.TrimPrefix(s.source[s.start+3:s.current], " ")
+		s.addTokenWithLiteral(DOC_COMMENT, strings.TrimRight(text, "\r"))
+	}
+}
+
+// blockComment skips a '/* ... */' comment, which can contain other block
+// comments
+func (s *Scanner) blockComment() {
+	s.dropDocComments()
+	depth := 1
+	for depth > 0 {
+		if s.isAtEnd() {
+			Emit(s.startLine, "Unterminated comment.")
+			return
+		}
+		switch c := s.advance(); {
+		case c == '\n':
+			s.lineEnd()
+			s.newline()
+		case c == '/' && s.match('*'):
+			depth++
+		case c == '*' && s.match('/'):
+			depth--
+		}
+	}
+}
+
+func (s *Scanner) openBracket(c rune) {
+	s.brackets = append(s.brackets, c)
+}
+
+func (s *Scanner) closeBracket() {
+	if len(s.brackets) > 0 {
+		s.brackets = s.brackets[:len(s.brackets)-1]
+	}
+}
+
+func (s *Scanner) lastTokenType() TokenType {
+	for j := len(s.tokens) - 1; j >= 0; j-- {
+		if s.tokens[j].TokenType != DOC_COMMENT {
+			return s.tokens[j].TokenType
+		}
+	}
+	return EOF
+}
+
+// lineEnd adds an EOL token when semicolons are optional and the line ends
+// with a token that can end a statement, like Go inserts semicolons. There are
+// no line ends within parentheses, brackets and string interpolations, but
+// there are within braces, also when these are in parentheses like the body of
+// an anonymous function passed as an argument.
+func (s *Scanner) lineEnd() {
+	if !s.optionalSemicolons || len(s.interpolations) > 0 {
+		return
+	}
+	if len(s.brackets) > 0 && s.brackets[len(s.brackets)-1] != '{' {
+		return
+	}
+	switch s.lastTokenType() {
+	case IDENTIFIER, STRING, NUMBER, TRUE, FALSE, NIL, THIS,
+		BREAK, CONTINUE, RETURN, YIELD,
+		RIGHT_PAREN, RIGHT_BRACKET, RIGHT_BRACE, PLUS_PLUS, MINUS_MINUS:
+		// the line end goes before a doc comment at the end of the line, which
+		// stays right before the declaration it documents
+		end := len(s.tokens)
+		for end > 0 && s.tokens[end-1].TokenType == DOC_COMMENT {
+			end--
+		}
+		lineEnd := Token{EOL, "", nil, s.line, s.column(), s.start, s.file}
+		s.tokens = append(s.tokens[:end], append([]Token{lineEnd}, s.tokens[end:]...)...)
+	}
+}
+
+// dropDocComments removes the doc comments at the end of the tokens
+func (s *Scanner) dropDocComments() {
+	for len(s.tokens) > 0 && s.tokens[len(s.tokens)-1].TokenType == DOC_COMMENT {
+		s.tokens = s.tokens[:len(s.tokens)-1]
+	}
+}
+
+func (s *Scanner) advance() rune {
+	next, width := utf8.DecodeRuneInString(s.source[s.current:])
+	s.current += width
 	return next
 }
 
-func (s *Scanner) match(expected byte) bool {
+func (s *Scanner) match(expected rune) bool {
 	if s.isAtEnd() {
 		return false
 	}
 
-	if s.source[s.current] != expected {
+	next, width := utf8.DecodeRuneInString(s.source[s.current:])
+	if next != expected {
 		return false
 	}
 
-	s.current++
+	s.current += width
 	return true
 }
 
-func (s *Scanner) peek() byte {
+func (s *Scanner) peek() rune {
 	if s.isAtEnd() {
-		return 0 // byte with ordinal 0 should not appear in the source code...?
-	} else {
-		return s.source[s.current]
+		return 0 // rune with ordinal 0 should not appear in the source code...?
 	}
+	next, _ := utf8.DecodeRuneInString(s.source[s.current:])
+	return next
 }
 
-func (s *Scanner) peekNext() byte {
-	nextCurrent := s.current + 1
-	if nextCurrent >= len(s.source) {
+func (s *Scanner) peekNext() rune {
+	if s.isAtEnd() {
 		return 0
 	}
-	return s.source[nextCurrent]
+	_, width := utf8.DecodeRuneInString(s.source[s.current:])
+	if s.current+width >= len(s.source) {
+		return 0
+	}
+	next, _ := utf8.DecodeRuneInString(s.source[s.current+width:])
+	return next
+}
+
+// newline is called after a '\n' is consumed
+func (s *Scanner) newline() {
+	s.line++
+	s.lineStart = s.current
+}
+
+// column returns the 1-based column of the current position, in runes
+func (s *Scanner) column() int {
+	return utf8.RuneCountInString(s.source[s.lineStart:s.current]) + 1
 }
 
 func (s *Scanner) addToken(tokenType TokenType) {
@@ -165,19 +408,34 @@ func (s *Scanner) addToken(tokenType TokenType) {
 
 func (s *Scanner) addTokenWithLiteral(tokenType TokenType, literal any) {
 	text := s.source[s.start:s.current]
-	s.tokens = append(s.tokens, Token{tokenType, text, literal, s.line})
+	s.tokens = append(s.tokens, Token{tokenType, text, literal, s.startLine, s.startColumn, s.start, s.file})
 }
 
 func (s *Scanner) isAtEnd() bool {
 	return s.current >= len(s.source)
 }
 
+// string scans a string literal up to the closing quote, or up to an embedded
+// "${" in which case an INTERPOLATION token is added and the scanner goes on
+// with the tokens of the embedded expression.
 func (s *Scanner) string() {
+	var value strings.Builder
 	for s.peek() != '"' && !s.isAtEnd() {
-		if s.peek() == '\n' {
-			s.line++
+		c := s.advance()
+		switch {
+		case c == '\n':
+			s.newline()
+			value.WriteRune(c)
+		case c == '\\':
+			s.escape(&value)
+		case c == '$' && s.peek() == '{':
+			s.advance()
+			s.addTokenWithLiteral(INTERPOLATION, value.String())
+			s.interpolations = append(s.interpolations, 0)
+			return
+		default:
+			value.WriteRune(c)
 		}
-		s.advance()
 	}
 
 	if s.isAtEnd() {
@@ -187,27 +445,181 @@ func (s *Scanner) string() {
 
 	// the closing "
 	s.advance()
-	s.addTokenWithLiteral(STRING, s.source[s.start+1:s.current-1])
+	s.addTokenWithLiteral(STRING, value.String())
 }
 
-func (s *Scanner) number() {
-	for isDigit(s.peek()) {
+// escape decodes the escape sequence following a backslash
+func (s *Scanner) escape(value *strings.Builder) {
+	if s.isAtEnd() {
+		return
+	}
+	c := s.advance()
+	switch c {
+	case 'n':
+		value.WriteByte('\n')
+	case 't':
+		value.WriteByte('\t')
+	case 'r':
+		value.WriteByte('\r')
+	case '0':
+		value.WriteByte(0)
+	case '"', '\\', '$':
+		value.WriteRune(c)
+	case 'u':
+		s.unicodeEscape(value)
+	default:
+		if c == '\n' {
+			s.newline()
+		}
+		Emit(s.line, fmt.Sprintf("Invalid escape sequence '\\%c'.", c))
+	}
+}
+
+// unicodeEscape decodes the "{XXXX}" part of a \u{XXXX} escape sequence
+func (s *Scanner) unicodeEscape(value *strings.Builder) {
+	if !s.match('{') {
+		Emit(s.line, "Expect '{' after '\\u'.")
+		return
+	}
+	digitsStart := s.current
+	for isHexDigit(s.peek()) {
 		s.advance()
 	}
+	digits := s.source[digitsStart:s.current]
+	if !s.match('}') {
+		Emit(s.line, "Expect '}' after unicode escape digits.")
+		return
+	}
 
+	if len(digits) == 0 || len(digits) > 6 {
+		Emit(s.line, "Unicode escape needs 1 to 6 hex digits.")
+		return
+	}
+	codePoint, _ := strconv.ParseInt(digits, 16, 32)
+	if !utf8.ValidRune(rune(codePoint)) {
+		Emit(s.line, fmt.Sprintf("Invalid unicode code point '%s'.", digits))
+		return
+	}
+	value.WriteRune(rune(codePoint))
+}
+
+// number scans a decimal literal (1_000, 3.14, 6.02e23) or, after a 0, a
+// hexadecimal (0xFF), binary (0b1010) or octal (0o755) integer. Underscores can
+// separate digits. A malformed literal is reported and scanned as 0, so that
+// the parser does not report it again.
+func (s *Scanner) number() {
+	if s.source[s.start] == '0' {
+		switch s.peek() {
+		case 'x', 'X':
+			s.radixNumber(16, "hexadecimal")
+			return
+		case 'b', 'B':
+			s.radixNumber(2, "binary")
+			return
+		case 'o', 'O':
+			s.radixNumber(8, "octal")
+			return
+		}
+	}
+
+	s.digits()
 	if s.peek() == '.' && isDigit(s.peekNext()) {
 		s.advance()
-		for isDigit(s.peek()) {
+		s.digits()
+	}
+	if s.peek() == 'e' || s.peek() == 'E' {
+		s.advance()
+		if s.peek() == '+' || s.peek() == '-' {
 			s.advance()
 		}
+		if !isDigit(s.peek()) {
+			s.numberError("Exponent needs at least one digit.")
+			return
+		}
+		s.digits()
+	}
+	if isAlpha(s.peek()) {
+		s.numberError("Invalid character in number literal.")
+		return
+	}
+
+	text := s.source[s.start:s.current]
+	if !validSeparators(text, isDigit) {
+		s.numberError("Separator '_' must be between digits.")
+		return
 	}
-	value, error := strconv.ParseFloat(s.source[s.start:s.current], 64)
-	if error != nil {
-		panic("Unexpected error")
+	value, err := strconv.ParseFloat(strings.ReplaceAll(text, "_", ""), 64)
+	if err != nil {
+		s.numberError("Number literal out of range.")
+		return
 	}
 	s.addTokenWithLiteral(NUMBER, value)
 }
 
+// digits consumes decimal digits and separators
+func (s *Scanner) digits() {
+	for isDigit(s.peek()) || s.peek() == '_' {
+		s.advance()
+	}
+}
+
+func (s *Scanner) radixNumber(base int, name string) {
+	s.advance() // x, b or o
+	digitsStart := s.current
+	for isAlphaNumeric(s.peek()) {
+		s.advance()
+	}
+	digits := s.source[digitsStart:s.current]
+
+	isBaseDigit := func(c rune) bool {
+		value, err := strconv.ParseInt(string(c), base, 8)
+		return err == nil && value < int64(base)
+	}
+	if digits == "" {
+		s.numberError(fmt.Sprintf("Expect digits after '%s'.", s.source[s.start:digitsStart]))
+		return
+	}
+	for _, c := range digits {
+		if c != '_' && !isBaseDigit(c) {
+			s.numberError(fmt.Sprintf("Invalid digit '%c' in %s literal.", c, name))
+			return
+		}
+	}
+	if !validSeparators(digits, isBaseDigit) {
+		s.numberError("Separator '_' must be between digits.")
+		return
+	}
+
+	// big enough for any number of digits, the float64 just gets less precise
+	value, _ := new(big.Int).SetString(strings.ReplaceAll(digits, "_", ""), base)
+	number, _ := new(big.Float).SetInt(value).Float64()
+	if math.IsInf(number, 0) {
+		s.numberError("Number literal out of range.")
+		return
+	}
+	s.addTokenWithLiteral(NUMBER, number)
+}
+
+// validSeparators tells whether every '_' in the literal is between two digits
+func validSeparators(text string, isDigit func(rune) bool) bool {
+	runes := []rune(text)
+	for j, c := range runes {
+		if c == '_' && (j == 0 || j == len(runes)-1 || !isDigit(runes[j-1]) || !isDigit(runes[j+1])) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Scanner) numberError(message string) {
+	// skip the rest of the literal
+	for isAlphaNumeric(s.peek()) {
+		s.advance()
+	}
+	Emit(s.line, message)
+	s.addTokenWithLiteral(NUMBER, 0.0)
+}
+
 func (s *Scanner) identifier() {
 	for isAlphaNumeric(s.peek()) {
 		s.advance()
@@ -0,0 +1,255 @@
+package lox
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStringEscapes(t *testing.T) {
+	testcases := map[string]string{
+		`"plain"`:        "plain",
+		`"a\tb"`:         "a\tb",
+		`"line\nbreak"`:  "line\nbreak",
+		`"\"quoted\""`:   "\"quoted\"",
+		`"back\\slash"`:  "back\\slash",
+		`"\${not}"`:      "${not}",
+		`"\u{41}\u{e9}"`: "Aé",
+		`"\u{1F600}"`:    "😀",
+	}
+
+	for source, expected := range testcases {
+		scanner := NewScanner(source)
+		tokens := scanner.ScanTokens()
+		if tokens[0].TokenType != STRING || tokens[0].Literal != expected {
+			t.Errorf("failed: %s scanned as %v, expected %q", source, tokens[0], expected)
+		}
+	}
+}
+
+func TestInvalidStringEscape(t *testing.T) {
+	defer func() { HadError = false }()
+
+	for _, source := range []string{`"\q"`, `"\u{110000}"`, `"\u41"`} {
+		HadError = false
+		scanner := NewScanner(source)
+		scanner.ScanTokens()
+		if !HadError {
+			t.Errorf("failed: %s was accepted", source)
+		}
+	}
+}
+
+func TestStringInterpolation(t *testing.T) {
+	scanner := NewScanner(`"a ${b} c ${ {"d": 1}["d"] } e"`)
+	tokens := scanner.ScanTokens()
+
+	expected := []TokenType{
+		INTERPOLATION, IDENTIFIER, INTERPOLATION,
+		LEFT_BRACE, STRING, COLON, NUMBER, RIGHT_BRACE,
+		LEFT_BRACKET, STRING, RIGHT_BRACKET,
+		STRING, EOF,
+	}
+	if len(tokens) != len(expected) {
+		t.Fatalf("failed: got %d tokens %v, expected %d", len(tokens), tokens, len(expected))
+	}
+	for j, tokenType := range expected {
+		if tokens[j].TokenType != tokenType {
+			t.Errorf("failed: token %d is %v, expected %v", j, tokens[j], tokenType)
+		}
+	}
+	if tokens[0].Literal != "a " || tokens[2].Literal != " c " || tokens[11].Literal != " e" {
+		t.Errorf("failed: wrong string parts %v", tokens)
+	}
+}
+
+func TestEllipsis(t *testing.T) {
+	scanner := NewScanner("(a, ...rest) a.b")
+	tokens := scanner.ScanTokens()
+
+	expected := []TokenType{
+		LEFT_PAREN, IDENTIFIER, COMMA, ELLIPSIS, IDENTIFIER, RIGHT_PAREN,
+		IDENTIFIER, DOT, IDENTIFIER, EOF,
+	}
+	if len(tokens) != len(expected) {
+		t.Fatalf("failed: got %d tokens %v, expected %d", len(tokens), tokens, len(expected))
+	}
+	for j, tokenType := range expected {
+		if tokens[j].TokenType != tokenType {
+			t.Errorf("failed: token %d is %v, expected %v", j, tokens[j], tokenType)
+		}
+	}
+}
+
+func TestTokenPositions(t *testing.T) {
+	scanner := NewScanner("var größe = \"ü\";\n  größe")
+	tokens := scanner.ScanTokens()
+
+	expected := []struct {
+		tokenType            TokenType
+		line, column, offset int
+	}{
+		{VAR, 1, 1, 0},
+		{IDENTIFIER, 1, 5, 4},
+		{EQUAL, 1, 11, 12},
+		{STRING, 1, 13, 14},
+		{SEMICOLON, 1, 16, 18},
+		{IDENTIFIER, 2, 3, 22},
+		{EOF, 2, 8, 29},
+	}
+	if len(tokens) != len(expected) {
+		t.Fatalf("failed: got %d tokens %v, expected %d", len(tokens), tokens, len(expected))
+	}
+	for j, e := range expected {
+		token := tokens[j]
+		if token.TokenType != e.tokenType || token.Line != e.line || token.Column != e.column || token.Offset != e.offset {
+			t.Errorf("failed: token %d is %v at %d:%d (offset %d), expected %v at %d:%d (offset %d)",
+				j, token, token.Line, token.Column, token.Offset, e.tokenType, e.line, e.column, e.offset)
+		}
+	}
+	if tokens[1].Lexeme != "größe" || tokens[3].Literal != "ü" {
+		t.Errorf("failed: wrong lexemes %v", tokens)
+	}
+}
+
+func TestUnexpectedCharacter(t *testing.T) {
+	defer func() { HadError = false }()
+
+	for _, source := range []string{"a ✓ b", "a \xff b"} {
+		HadError = false
+		scanner := NewScanner(source)
+		tokens := scanner.ScanTokens()
+		if !HadError {
+			t.Errorf("failed: %q was accepted", source)
+		}
+		if len(tokens) != 3 || tokens[1].Lexeme != "b" {
+			t.Errorf("failed: %q scanned as %v", source, tokens)
+		}
+	}
+}
+
+func TestNumberLiterals(t *testing.T) {
+	tests := map[string]float64{
+		"255":         255,
+		"0xFF":        255,
+		"0Xff":        255,
+		"0b1010":      10,
+		"0o755":       493,
+		"1e-9":        1e-9,
+		"6.02E23":     6.02e23,
+		"2.5e+3":      2500,
+		"1_000_000":   1000000,
+		"0xFF_FF":     65535,
+		"1_0.0_1e1_0": 10.01e10,
+	}
+	for source, expected := range tests {
+		scanner := NewScanner(source)
+		tokens := scanner.ScanTokens()
+		if len(tokens) != 2 || tokens[0].TokenType != NUMBER || tokens[0].Literal != expected {
+			t.Errorf("failed: %q scanned as %v, expected %v", source, tokens, expected)
+		}
+	}
+}
+
+func TestInvalidNumberLiteral(t *testing.T) {
+	defer func() { HadError = false }()
+
+	for _, source := range []string{"0x", "0b102", "0o8", "1__0", "1_", "0x_1", "1e", "1e+", "12abc", "1_.5"} {
+		HadError = false
+		scanner := NewScanner(source + " b")
+		tokens := scanner.ScanTokens()
+		if !HadError {
+			t.Errorf("failed: %q was accepted", source)
+		}
+		if len(tokens) != 3 || tokens[0].TokenType != NUMBER || tokens[1].Lexeme != "b" {
+			t.Errorf("failed: %q scanned as %v", source, tokens)
+		}
+	}
+}
+
+func TestBlockComments(t *testing.T) {
+	source := "a /* one /* two */\n still one */ b\n/**/ c"
+	scanner := NewScanner(source)
+	tokens := scanner.ScanTokens()
+	expected := []Token{
+		{IDENTIFIER, "a", nil, 1, 1, 0, ""},
+		{IDENTIFIER, "b", nil, 2, 15, 33, ""},
+		{IDENTIFIER, "c", nil, 3, 6, 40, ""},
+		{EOF, "", "", 3, 7, 41, ""},
+	}
+	if len(tokens) != len(expected) {
+		t.Fatalf("failed: %q scanned as %v", source, tokens)
+	}
+	for j, token := range tokens {
+		if token != expected[j] {
+			t.Errorf("failed: token %d is %v, expected %v", j, token, expected[j])
+		}
+	}
+}
+
+func TestUnterminatedBlockComment(t *testing.T) {
+	defer func() { HadError = false }()
+
+	for _, source := range []string{"a /* b", "a /* /* b */"} {
+		HadError = false
+		scanner := NewScanner(source)
+		scanner.ScanTokens()
+		if !HadError {
+			t.Errorf("failed: %q was accepted", source)
+		}
+	}
+}
+
+func TestDocComments(t *testing.T) {
+	source := "/// doc\n////not doc\n//  not doc either\n///second line\nx"
+	scanner := NewScanner(source)
+	tokens := scanner.ScanTokens()
+	if len(tokens) != 4 || tokens[0].Literal != "doc" || tokens[1].Literal != "second line" || tokens[2].Lexeme != "x" {
+		t.Errorf("failed: %q scanned as %v", source, tokens)
+	}
+	for _, token := range tokens[:2] {
+		if token.TokenType != DOC_COMMENT {
+			t.Errorf("failed: %v is not a doc comment", token)
+		}
+	}
+}
+
+func TestLineEnds(t *testing.T) {
+	testcases := map[string]string{
+		"a = 1\nb":                "a = 1 EOL b EOL EOF",
+		"a +\nb":                  "a + b EOL EOF",
+		"f(a,\nb)\n":              "f ( a , b ) EOL EOF",
+		"{ return x }":            "{ return x EOL } EOL EOF",
+		"{\n}\n":                  "{ } EOL EOF",
+		"return\n":                "return EOL EOF",
+		"x++ /* one\ntwo */ y":    "x ++ EOL y EOL EOF",
+		"f(fun (x) { return x })": "f ( fun ( x ) { return x EOL } ) EOL EOF",
+		"[(x) => { return x }]":   "[ ( x ) => { return x EOL } ] EOL EOF",
+		"f(fun () {\na\nb\n})":    "f ( fun ( ) { a EOL b EOL } ) EOL EOF",
+		"\"${a\n}\" // comment\n": "INTERPOLATION a STRING EOL EOF",
+	}
+	for source, expected := range testcases {
+		scanner := NewScanner(source)
+		scanner.SetOptionalSemicolons(true)
+		var scanned []string
+		for _, token := range scanner.ScanTokens() {
+			switch token.TokenType {
+			case EOL, EOF, INTERPOLATION, STRING:
+				scanned = append(scanned, token.TokenType.String())
+			default:
+				scanned = append(scanned, token.Lexeme)
+			}
+		}
+		if strings.Join(scanned, " ") != expected {
+			t.Errorf("failed: %q scanned as %s, expected %s", source, strings.Join(scanned, " "), expected)
+		}
+	}
+}
+
+func TestNoLineEndsByDefault(t *testing.T) {
+	scanner := NewScanner("a = 1\nb\n")
+	for _, token := range scanner.ScanTokens() {
+		if token.TokenType == EOL {
+			t.Errorf("failed: line end scanned in strict mode")
+		}
+	}
+}
@@ -2,50 +2,134 @@
 package lox
 
 type StmtVisitor interface {
-  VisitExpressionStmt(stmt ExpressionStmt) (any, LoxError)
-  VisitFunctionStmt(stmt FunctionStmt) (any, LoxError)
-  VisitIfStmt(stmt IfStmt) (any, LoxError)
-  VisitPrintStmt(stmt PrintStmt) (any, LoxError)
-  VisitBlockStmt(stmt BlockStmt) (any, LoxError)
-  VisitReturnStmt(stmt ReturnStmt) (any, LoxError)
-  VisitVarStmt(stmt VarStmt) (any, LoxError)
-  VisitWhileStmt(stmt WhileStmt) (any, LoxError)
+  VisitBreakStmt(stmt *BreakStmt) (any, LoxError)
+  VisitConstStmt(stmt *ConstStmt) (any, LoxError)
+  VisitContinueStmt(stmt *ContinueStmt) (any, LoxError)
+  VisitExpressionStmt(stmt *ExpressionStmt) (any, LoxError)
+  VisitForInStmt(stmt *ForInStmt) (any, LoxError)
+  VisitFunctionStmt(stmt *FunctionStmt) (any, LoxError)
+  VisitIfStmt(stmt *IfStmt) (any, LoxError)
+  VisitImportStmt(stmt *ImportStmt) (any, LoxError)
+  VisitMatchStmt(stmt *MatchStmt) (any, LoxError)
+  VisitPrintStmt(stmt *PrintStmt) (any, LoxError)
+  VisitBlockStmt(stmt *BlockStmt) (any, LoxError)
+  VisitClassStmt(stmt *ClassStmt) (any, LoxError)
+  VisitReturnStmt(stmt *ReturnStmt) (any, LoxError)
+  VisitThrowStmt(stmt *ThrowStmt) (any, LoxError)
+  VisitTryStmt(stmt *TryStmt) (any, LoxError)
+  VisitVarStmt(stmt *VarStmt) (any, LoxError)
+  VisitWhileStmt(stmt *WhileStmt) (any, LoxError)
+  VisitYieldStmt(stmt *YieldStmt) (any, LoxError)
 }
 
 type Stmt interface {
   Accept(visitor StmtVisitor) (any, LoxError)
 }
 
+//  -------------------------------------------------------------
+type BreakStmt struct {
+  keyword Token
+}
+
+func NewBreakStmt(keyword Token) *BreakStmt {
+  return &BreakStmt{
+    keyword:keyword,
+  }
+}
+
+func (c *BreakStmt) Accept(visitor StmtVisitor) (any, LoxError) {
+  return visitor.VisitBreakStmt(c)
+}
+//  -------------------------------------------------------------
+type ConstStmt struct {
+  name Token
+  initializer Expr
+  doc string
+}
+
+func NewConstStmt(name Token, initializer Expr, doc string) *ConstStmt {
+  return &ConstStmt{
+    name:name,
+    initializer:initializer,
+    doc:doc,
+  }
+}
+
+func (c *ConstStmt) Accept(visitor StmtVisitor) (any, LoxError) {
+  return visitor.VisitConstStmt(c)
+}
+//  -------------------------------------------------------------
+type ContinueStmt struct {
+  keyword Token
+}
+
+func NewContinueStmt(keyword Token) *ContinueStmt {
+  return &ContinueStmt{
+    keyword:keyword,
+  }
+}
+
+func (c *ContinueStmt) Accept(visitor StmtVisitor) (any, LoxError) {
+  return visitor.VisitContinueStmt(c)
+}
 //  -------------------------------------------------------------
 type ExpressionStmt struct {
   expression Expr
 }
 
-func NewExpressionStmt(expression Expr) ExpressionStmt {
-  return ExpressionStmt{
+func NewExpressionStmt(expression Expr) *ExpressionStmt {
+  return &ExpressionStmt{
     expression:expression,
   }
 }
 
-func (c ExpressionStmt) Accept(visitor StmtVisitor) (any, LoxError) {
+func (c *ExpressionStmt) Accept(visitor StmtVisitor) (any, LoxError) {
   return visitor.VisitExpressionStmt(c)
 }
 //  -------------------------------------------------------------
+type ForInStmt struct {
+  keyword Token
+  name Token
+  iterable Expr
+  body Stmt
+}
+
+func NewForInStmt(keyword Token, name Token, iterable Expr, body Stmt) *ForInStmt {
+  return &ForInStmt{
+    keyword:keyword,
+    name:name,
+    iterable:iterable,
+    body:body,
+  }
+}
+
+func (c *ForInStmt) Accept(visitor StmtVisitor) (any, LoxError) {
+  return visitor.VisitForInStmt(c)
+}
+//  -------------------------------------------------------------
 type FunctionStmt struct {
   name Token
   params []Token
+  defaults []Expr
+  rest Token
   body []Stmt
+  generator bool
+  doc string
 }
 
-func NewFunctionStmt(name Token, params []Token, body []Stmt) FunctionStmt {
-  return FunctionStmt{
+func NewFunctionStmt(name Token, params []Token, defaults []Expr, rest Token, body []Stmt, generator bool, doc string) *FunctionStmt {
+  return &FunctionStmt{
     name:name,
     params:params,
+    defaults:defaults,
+    rest:rest,
     body:body,
+    generator:generator,
+    doc:doc,
   }
 }
 
-func (c FunctionStmt) Accept(visitor StmtVisitor) (any, LoxError) {
+func (c *FunctionStmt) Accept(visitor StmtVisitor) (any, LoxError) {
   return visitor.VisitFunctionStmt(c)
 }
 //  -------------------------------------------------------------
@@ -55,29 +139,65 @@ type IfStmt struct {
   elseBranch Stmt
 }
 
-func NewIfStmt(condition Expr, thenBranch Stmt, elseBranch Stmt) IfStmt {
-  return IfStmt{
+func NewIfStmt(condition Expr, thenBranch Stmt, elseBranch Stmt) *IfStmt {
+  return &IfStmt{
     condition:condition,
     thenBranch:thenBranch,
     elseBranch:elseBranch,
   }
 }
 
-func (c IfStmt) Accept(visitor StmtVisitor) (any, LoxError) {
+func (c *IfStmt) Accept(visitor StmtVisitor) (any, LoxError) {
   return visitor.VisitIfStmt(c)
 }
 //  -------------------------------------------------------------
+type ImportStmt struct {
+  keyword Token
+  path Token
+  name Token
+}
+
+func NewImportStmt(keyword Token, path Token, name Token) *ImportStmt {
+  return &ImportStmt{
+    keyword:keyword,
+    path:path,
+    name:name,
+  }
+}
+
+func (c *ImportStmt) Accept(visitor StmtVisitor) (any, LoxError) {
+  return visitor.VisitImportStmt(c)
+}
+//  -------------------------------------------------------------
+type MatchStmt struct {
+  keyword Token
+  subject Expr
+  arms []*MatchArm
+}
+
+func NewMatchStmt(keyword Token, subject Expr, arms []*MatchArm) *MatchStmt {
+  return &MatchStmt{
+    keyword:keyword,
+    subject:subject,
+    arms:arms,
+  }
+}
+
+func (c *MatchStmt) Accept(visitor StmtVisitor) (any, LoxError) {
+  return visitor.VisitMatchStmt(c)
+}
+//  -------------------------------------------------------------
 type PrintStmt struct {
   expression Expr
 }
 
-func NewPrintStmt(expression Expr) PrintStmt {
-  return PrintStmt{
+func NewPrintStmt(expression Expr) *PrintStmt {
+  return &PrintStmt{
     expression:expression,
   }
 }
 
-func (c PrintStmt) Accept(visitor StmtVisitor) (any, LoxError) {
+func (c *PrintStmt) Accept(visitor StmtVisitor) (any, LoxError) {
   return visitor.VisitPrintStmt(c)
 }
 //  -------------------------------------------------------------
@@ -85,60 +205,136 @@ type BlockStmt struct {
   statements []Stmt
 }
 
-func NewBlockStmt(statements []Stmt) BlockStmt {
-  return BlockStmt{
+func NewBlockStmt(statements []Stmt) *BlockStmt {
+  return &BlockStmt{
     statements:statements,
   }
 }
 
-func (c BlockStmt) Accept(visitor StmtVisitor) (any, LoxError) {
+func (c *BlockStmt) Accept(visitor StmtVisitor) (any, LoxError) {
   return visitor.VisitBlockStmt(c)
 }
 //  -------------------------------------------------------------
+type ClassStmt struct {
+  name Token
+  superclass *VariableExpr
+  methods []*FunctionStmt
+  doc string
+}
+
+func NewClassStmt(name Token, superclass *VariableExpr, methods []*FunctionStmt, doc string) *ClassStmt {
+  return &ClassStmt{
+    name:name,
+    superclass:superclass,
+    methods:methods,
+    doc:doc,
+  }
+}
+
+func (c *ClassStmt) Accept(visitor StmtVisitor) (any, LoxError) {
+  return visitor.VisitClassStmt(c)
+}
+//  -------------------------------------------------------------
 type ReturnStmt struct {
   keyword Token
   value Expr
 }
 
-func NewReturnStmt(keyword Token, value Expr) ReturnStmt {
-  return ReturnStmt{
+func NewReturnStmt(keyword Token, value Expr) *ReturnStmt {
+  return &ReturnStmt{
     keyword:keyword,
     value:value,
   }
 }
 
-func (c ReturnStmt) Accept(visitor StmtVisitor) (any, LoxError) {
+func (c *ReturnStmt) Accept(visitor StmtVisitor) (any, LoxError) {
   return visitor.VisitReturnStmt(c)
 }
 //  -------------------------------------------------------------
+type ThrowStmt struct {
+  keyword Token
+  value Expr
+}
+
+func NewThrowStmt(keyword Token, value Expr) *ThrowStmt {
+  return &ThrowStmt{
+    keyword:keyword,
+    value:value,
+  }
+}
+
+func (c *ThrowStmt) Accept(visitor StmtVisitor) (any, LoxError) {
+  return visitor.VisitThrowStmt(c)
+}
+//  -------------------------------------------------------------
+type TryStmt struct {
+  tryBlock []Stmt
+  catchName Token
+  catchBlock []Stmt
+  finallyBlock []Stmt
+}
+
+func NewTryStmt(tryBlock []Stmt, catchName Token, catchBlock []Stmt, finallyBlock []Stmt) *TryStmt {
+  return &TryStmt{
+    tryBlock:tryBlock,
+    catchName:catchName,
+    catchBlock:catchBlock,
+    finallyBlock:finallyBlock,
+  }
+}
+
+func (c *TryStmt) Accept(visitor StmtVisitor) (any, LoxError) {
+  return visitor.VisitTryStmt(c)
+}
+//  -------------------------------------------------------------
 type VarStmt struct {
   name Token
   initializer Expr
+  doc string
 }
 
-func NewVarStmt(name Token, initializer Expr) VarStmt {
-  return VarStmt{
+func NewVarStmt(name Token, initializer Expr, doc string) *VarStmt {
+  return &VarStmt{
     name:name,
     initializer:initializer,
+    doc:doc,
   }
 }
 
-func (c VarStmt) Accept(visitor StmtVisitor) (any, LoxError) {
+func (c *VarStmt) Accept(visitor StmtVisitor) (any, LoxError) {
   return visitor.VisitVarStmt(c)
 }
 //  -------------------------------------------------------------
 type WhileStmt struct {
   condition Expr
   body Stmt
+  increment Expr
 }
 
-func NewWhileStmt(condition Expr, body Stmt) WhileStmt {
-  return WhileStmt{
+func NewWhileStmt(condition Expr, body Stmt, increment Expr) *WhileStmt {
+  return &WhileStmt{
     condition:condition,
     body:body,
+    increment:increment,
   }
 }
 
-func (c WhileStmt) Accept(visitor StmtVisitor) (any, LoxError) {
+func (c *WhileStmt) Accept(visitor StmtVisitor) (any, LoxError) {
   return visitor.VisitWhileStmt(c)
 }
+//  -------------------------------------------------------------
+type YieldStmt struct {
+  keyword Token
+  value Expr
+}
+
+func NewYieldStmt(keyword Token, value Expr) *YieldStmt {
+  return &YieldStmt{
+    keyword:keyword,
+    value:value,
+  }
+}
+
+func (c *YieldStmt) Accept(visitor StmtVisitor) (any, LoxError) {
+  return visitor.VisitYieldStmt(c)
+}
@@ -13,13 +13,18 @@ const (
 	RIGHT_PAREN
 	LEFT_BRACE
 	RIGHT_BRACE
+	LEFT_BRACKET
+	RIGHT_BRACKET
 	COMMA
+	COLON
 	DOT
 	MINUS
 	PLUS
 	SEMICOLON
 	SLASH
 	STAR
+	PERCENT
+	QUESTION
 
 	// One or two character tokens
 	BANG
@@ -30,29 +35,54 @@ const (
 	GREATER_EQUAL
 	LESS
 	LESS_EQUAL
+	STAR_STAR
+	TILDE_SLASH
+	ARROW
+	QUESTION_QUESTION
+	PLUS_EQUAL
+	MINUS_EQUAL
+	STAR_EQUAL
+	SLASH_EQUAL
+	PLUS_PLUS
+	MINUS_MINUS
+	ELLIPSIS
 
 	// literals
 	IDENTIFIER
 	STRING
+	INTERPOLATION // the part of a string literal before an embedded "${"
 	NUMBER
+	DOC_COMMENT // a '///' comment, the literal is its text
 
 	// keywords
 	AND
+	BREAK
+	CASE
+	CATCH
 	CLASS
+	CONST
+	CONTINUE
 	ELSE
 	FALSE
+	FINALLY
 	FUN
 	FOR
 	IF
+	IMPORT
+	IN
+	MATCH
 	NIL
 	OR
 	PRINT
 	RETURN
 	SUPER
 	THIS
+	THROW
 	TRUE
+	TRY
 	VAR
 	WHILE
+	YIELD
 
 	EOL
 	EOF
@@ -64,13 +94,18 @@ func (tt TokenType) String() string {
 		"RIGHT_PAREN",
 		"LEFT_BRACE",
 		"RIGHT_BRACE",
+		"LEFT_BRACKET",
+		"RIGHT_BRACKET",
 		"COMMA",
+		"COLON",
 		"DOT",
 		"MINUS",
 		"PLUS",
 		"SEMICOLON",
 		"SLASH",
 		"STAR",
+		"PERCENT",
+		"QUESTION",
 		"BANG",
 		"BANG_EQUAL",
 		"EQUAL",
@@ -79,25 +114,50 @@ func (tt TokenType) String() string {
 		"GREATER_EQUAL",
 		"LESS",
 		"LESS_EQUAL",
+		"STAR_STAR",
+		"TILDE_SLASH",
+		"ARROW",
+		"QUESTION_QUESTION",
+		"PLUS_EQUAL",
+		"MINUS_EQUAL",
+		"STAR_EQUAL",
+		"SLASH_EQUAL",
+		"PLUS_PLUS",
+		"MINUS_MINUS",
+		"ELLIPSIS",
 		"IDENTIFIER",
 		"STRING",
+		"INTERPOLATION",
 		"NUMBER",
+		"DOC_COMMENT",
 		"AND",
+		"BREAK",
+		"CASE",
+		"CATCH",
 		"CLASS",
+		"CONST",
+		"CONTINUE",
 		"ELSE",
 		"FALSE",
+		"FINALLY",
 		"FUN",
 		"FOR",
 		"IF",
+		"IMPORT",
+		"IN",
+		"MATCH",
 		"NIL",
 		"OR",
 		"PRINT",
 		"RETURN",
 		"SUPER",
 		"THIS",
+		"THROW",
 		"TRUE",
+		"TRY",
 		"VAR",
 		"WHILE",
+		"YIELD",
 		"EOL",
 		"EOF",
 	}[tt]
@@ -105,22 +165,34 @@ func (tt TokenType) String() string {
 
 // ===========================================================================================
 var keywords = map[string]TokenType{
-	"and":    AND,
-	"class":  CLASS,
-	"else":   ELSE,
-	"false":  FALSE,
-	"for":    FOR,
-	"fun":    FUN,
-	"if":     IF,
-	"nil":    NIL,
-	"or":     OR,
-	"print":  PRINT,
-	"return": RETURN,
-	"super":  SUPER,
-	"this":   THIS,
-	"true":   TRUE,
-	"var":    VAR,
-	"while":  WHILE,
+	"and":      AND,
+	"break":    BREAK,
+	"case":     CASE,
+	"catch":    CATCH,
+	"class":    CLASS,
+	"const":    CONST,
+	"continue": CONTINUE,
+	"else":     ELSE,
+	"false":    FALSE,
+	"finally":  FINALLY,
+	"for":      FOR,
+	"fun":      FUN,
+	"if":       IF,
+	"import":   IMPORT,
+	"in":       IN,
+	"match":    MATCH,
+	"nil":      NIL,
+	"or":       OR,
+	"print":    PRINT,
+	"return":   RETURN,
+	"super":    SUPER,
+	"this":     THIS,
+	"throw":    THROW,
+	"true":     TRUE,
+	"try":      TRY,
+	"var":      VAR,
+	"while":    WHILE,
+	"yield":    YIELD,
 }
 
 // ===========================================================================================
@@ -129,6 +201,12 @@ type Token struct {
 	Lexeme    string
 	Literal   any
 	Line      int
+	// 1-based, counted in runes
+	Column int
+	// in bytes from the start of the source
+	Offset int
+	// absolute path of the file the token is in, "" when it is not from a file
+	File string
 }
 
 func (t Token) String() string {
@@ -3,3 +3,7 @@
 for a_test in examples/test_*.glox; do
   ./glox $a_test
 done
+
+for a_test in examples/optional_semicolons/test_*.glox; do
+  ./glox -optional-semicolons $a_test
+done